@@ -0,0 +1,9 @@
+// Command goshield runs GoShield in the mode GOSHIELD_MODE selects:
+// "server" (default) or "gateway".
+package main
+
+import "github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/app"
+
+func main() {
+	app.Run("")
+}
@@ -1,43 +1,9 @@
+// Command server runs GoShield in server (middleware) mode, whatever
+// GOSHIELD_MODE says. Kept for existing deployments; see cmd/goshield.
 package main
 
-import (
-	"os"
-	"strconv"
-
-	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
-	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/handlers"
-	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/middleware"
-
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-)
+import "github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/app"
 
 func main() {
-	// Load .env
-	godotenv.Load()
-
-	rateLimit, windowSeconds := 100, 60
-
-	if v := os.Getenv("RATE_LIMIT"); v != "" {
-		if x, err := strconv.Atoi(v); err == nil {
-			rateLimit = x
-		}
-	}
-
-	if v := os.Getenv("WINDOW_SECONDS"); v != "" {
-		if x, err := strconv.Atoi(v); err == nil {
-			windowSeconds = x
-		}
-	}
-
-	mode := os.Getenv("RATE_LIMIT_MODE") // "sliding" (default) or "fixed"
-
-	// Connect Redis
-	config.ConnectRedis()
-
-	r := gin.Default()
-	r.Use(middleware.RateLimiter(rateLimit, windowSeconds, mode))
-
-	r.GET("/health", handlers.HealthCheck)
-	r.Run(":8080")
+	app.Run(app.ModeServer)
 }
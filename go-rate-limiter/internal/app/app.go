@@ -0,0 +1,214 @@
+// Package app wires GoShield together from its configuration and runs it,
+// in one of two modes:
+//
+//   - server:  Gin with the rate limiter in front of its own routes
+//     (middleware mode);
+//   - gateway: the rate limiter in front of a reverse proxy to one or
+//     more upstreams.
+//
+// Both modes share config loading, the store and the limiter's settings,
+// so a setting means the same thing in either. cmd/goshield picks the mode
+// from GOSHIELD_MODE; cmd/server and cmd/gateway run a fixed mode.
+package app
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/handlers"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/logging"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/middleware"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/server"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Modes, for Run and GOSHIELD_MODE.
+const (
+	ModeServer  = "server"
+	ModeGateway = "gateway"
+)
+
+// Run starts GoShield in mode and serves until SIGTERM / SIGINT. An empty
+// mode is read from GOSHIELD_MODE (env or .env), defaulting to server.
+// Invalid configuration exits the process.
+func Run(mode string) {
+	// Load .env (ignore error – env vars may come from Docker/OS)
+	config.LoadDotEnv(".env")
+
+	// Logging (LOG_FORMAT=text|json, LOG_LEVEL)
+	logging.Setup()
+
+	if mode == "" {
+		mode = os.Getenv("GOSHIELD_MODE")
+	}
+	if mode == "" {
+		mode = ModeServer
+	}
+	if mode != ModeServer && mode != ModeGateway {
+		logging.Fatal(`unknown GOSHIELD_MODE: use "server" or "gateway"`, "mode", mode)
+	}
+
+	// Optional YAML config; environment variables override its values,
+	// and command-line flags override both.
+	flags := []config.EnvFlag{
+		{Name: "limit", Env: "RATE_LIMIT", Usage: "max requests per window"},
+		{Name: "window", Env: "WINDOW_SECONDS", Usage: "window duration in seconds"},
+		{Name: "mode", Env: "RATE_LIMIT_MODE", Usage: "sliding, sliding_counter, fixed, token_bucket, leaky_bucket or calendar"},
+		{Name: "port", Env: "PORT", Usage: "port to listen on"},
+	}
+	if mode == ModeGateway {
+		flags = append(flags, config.EnvFlag{Name: "upstream", Env: "UPSTREAM_URL", Usage: "upstream URL(s), comma-separated"})
+	}
+	configPath := flag.String("config", "", "path to a YAML config file")
+	applyFlags := config.BindEnvFlags(flags)
+	flag.Parse()
+	applyFlags()
+	if *configPath != "" {
+		if _, err := config.Load(*configPath); err != nil {
+			logging.Fatal("invalid config file", "err", err)
+		}
+		slog.Info("config file loaded", "path", *configPath)
+	}
+
+	var mountGateway func(r *gin.Engine, limits middleware.Options) func()
+	if mode == ModeGateway {
+		mountGateway = gatewayFromEnv()
+	}
+
+	settings := middleware.NewLiveSettings(loadSettings())
+	limits, auditWebhook := limitsFromEnv()
+	limits.Live = settings
+
+	// How long shutdown waits for in-flight (proxied) requests to finish.
+	shutdownTimeout := 10 * time.Second
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			shutdownTimeout = time.Duration(x) * time.Second
+		}
+	}
+
+	// ── Storage (Redis by default) ───────────────────────────────
+	limits.Store = config.NewStore()
+
+	// Per-identifier limits from the goshield:overrides Redis hash.
+	if v := os.Getenv("RATE_LIMIT_OVERRIDES"); v != "" {
+		if x, err := strconv.ParseBool(v); err == nil && x {
+			if config.RDB == nil {
+				logging.Fatal("RATE_LIMIT_OVERRIDES requires the Redis store")
+			}
+			// Re-read the hash at most every 10s: HSET changes apply within that.
+			limits.Overrides = middleware.RedisOverrides(config.RDB, middleware.OverridesKey, 10*time.Second)
+		}
+	}
+
+	// Every limit scaled by the goshield:limit_multiplier Redis key, for
+	// an external controller tracking upstream health.
+	if v := os.Getenv("RATE_LIMIT_MULTIPLIER"); v != "" {
+		if x, err := strconv.ParseBool(v); err == nil && x {
+			if config.RDB == nil {
+				logging.Fatal("RATE_LIMIT_MULTIPLIER requires the Redis store")
+			}
+			// Re-read the key at most every 5s: SET changes apply within that.
+			limits.LimitMultiplier = middleware.RedisLimitMultiplier(config.RDB, middleware.MultiplierKey, 5*time.Second)
+		}
+	}
+
+	// Refuse a bad configuration here, where exiting is fine, rather than
+	// let the middleware constructors panic on it.
+	if err := limits.Validate(); err != nil {
+		logging.Fatal("invalid rate limit settings", "err", err)
+	}
+	if _, ok := limits.Store.(ratelimiter.AdminStore); !ok && os.Getenv("ADMIN_TOKEN") != "" {
+		logging.Fatal("ADMIN_TOKEN is set but the store does not support inspection")
+	}
+	if _, ok := limits.Store.(ratelimiter.PeekStore); !ok && os.Getenv("QUOTA_PATH") != "" {
+		logging.Fatal("QUOTA_PATH is set but the store cannot peek at usage")
+	}
+
+	// ── Tracing (no-op unless OTEL_ENABLED=true) ─────────────────
+	shutdownTracing := tracing.Setup()
+	defer shutdownTracing(context.Background())
+
+	// ── Gin router ───────────────────────────────────────────────
+	r := gin.New()
+	// With HASH_KEYS the access log leaves client IPs out.
+	if logger := middleware.RequestLogger(limits.LogMode, limits.RejectStatus, limits.HashKeys); logger != nil {
+		r.Use(logger)
+	}
+	r.Use(gin.Recovery())
+	config.SetTrustedProxies(r) // real client IP behind load balancers
+	r.Use(tracing.Middleware())
+	r.Use(middleware.RequestID()) // X-Request-ID: echoed to the client, forwarded upstream
+
+	// Admin API (ADMIN_TOKEN) – registered before the limiter, so support
+	// can always reach it, even from a throttled IP.
+	handlers.RegisterAdmin(r, limits.Store, os.Getenv("ADMIN_TOKEN"))
+	handlers.RegisterDebugConfig(r, os.Getenv("ADMIN_TOKEN"), settings)
+
+	// GET QUOTA_PATH (e.g. /me/quota) reports the caller's usage without
+	// spending any – registered before the limiter, so asking is free,
+	// and never forwarded upstream.
+	if quotaPath := os.Getenv("QUOTA_PATH"); quotaPath != "" {
+		r.GET(quotaPath, middleware.QuotaHandler(limits))
+	}
+
+	closeGateway := func() {}
+	switch mode {
+	case ModeGateway:
+		// Liveness / readiness probes – no rate limiting, not forwarded upstream.
+		r.GET("/health", handlers.HealthCheck)
+		r.GET("/ready", handlers.ReadyCheck)
+
+		// All other routes: rate-limit first, then forward to upstream.
+		closeGateway = mountGateway(r, limits)
+	default:
+		r.Use(middleware.RateLimiterWithOptions(limits))
+
+		// Probes are exempt through SKIP_PATHS (/health and /ready by default).
+		r.GET("/health", handlers.HealthCheck)
+		r.GET("/ready", handlers.ReadyCheck)
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	// Hot reload: edit the config file (or .env) and save it, or send SIGHUP.
+	reloadPath := ".env"
+	if *configPath != "" {
+		reloadPath = *configPath
+	}
+	stopReload := config.WatchReload(reloadPath, func() {
+		if *configPath == "" {
+			if err := config.LoadDotEnv(".env"); err != nil {
+				slog.Error("config reload failed, keeping current settings", "err", err)
+				return
+			}
+			applyFlags()
+		} else if _, err := config.Load(*configPath); err != nil {
+			slog.Error("config reload failed, keeping current settings", "err", err)
+			return
+		}
+		settings.Store(loadSettings())
+	})
+
+	slog.Info("GoShield listening", "mode", mode, "port", port)
+	if err := server.Run(":"+port, r, shutdownTimeout); err != nil {
+		slog.Error("GoShield failed", "mode", mode, "err", err)
+	}
+	stopReload()
+	if auditWebhook != nil {
+		auditWebhook.Close(5 * time.Second)
+	}
+	closeGateway()
+	config.CloseRedis()
+}
@@ -0,0 +1,243 @@
+package app
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/gateway"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/logging"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// gatewayFromEnv reads gateway mode's upstream and proxy settings,
+// exiting on invalid ones, and returns the function that mounts the
+// reverse proxy behind the limiter on r. Mounting needs the store to be
+// connected (the response cache uses its Redis); the function it returns
+// stops the upstream health checks.
+func gatewayFromEnv() func(r *gin.Engine, limits middleware.Options) func() {
+	// ── Upstreams (UPSTREAM_URL and/or ROUTES) ───────────────────
+	upstreamURL := os.Getenv("UPSTREAM_URL")
+
+	// ROUTES=/auth=http://auth:8000,/billing=http://billing:8000
+	routes, err := gateway.ParseRoutes(os.Getenv("ROUTES"))
+	if err != nil {
+		logging.Fatal("invalid ROUTES", "err", err)
+	}
+
+	if upstreamURL == "" && len(routes) == 0 {
+		logging.Fatal("UPSTREAM_URL or ROUTES environment variable is required in gateway mode")
+	}
+
+	var upstreams []string
+	for _, u := range strings.Split(upstreamURL, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			upstreams = append(upstreams, u)
+		}
+	}
+
+	// Upstream health checks: GET <upstream><path> every interval; 0 disables.
+	healthPath := os.Getenv("UPSTREAM_HEALTH_PATH")
+	if healthPath == "" {
+		healthPath = "/health"
+	}
+	healthInterval := 10 * time.Second
+	if v := os.Getenv("UPSTREAM_HEALTH_INTERVAL"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			healthInterval = time.Duration(x) * time.Second
+		}
+	}
+
+	// Upstream timeouts (seconds): connect, wait for response headers,
+	// and keep idle keep-alive connections.
+	transportCfg := gateway.TransportConfig{
+		DialTimeout:           5 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	if v := os.Getenv("UPSTREAM_DIAL_TIMEOUT"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			transportCfg.DialTimeout = time.Duration(x) * time.Second
+		}
+	}
+	if v := os.Getenv("UPSTREAM_TIMEOUT"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			transportCfg.ResponseHeaderTimeout = time.Duration(x) * time.Second
+		}
+	}
+	if v := os.Getenv("UPSTREAM_IDLE_TIMEOUT"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			transportCfg.IdleConnTimeout = time.Duration(x) * time.Second
+		}
+	}
+
+	// Retries for GET / HEAD / OPTIONS on connection errors or 502 / 503.
+	upstreamRetries := 0
+	if v := os.Getenv("UPSTREAM_RETRIES"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			upstreamRetries = x
+		}
+	}
+
+	// Circuit breaker: after BREAKER_THRESHOLD consecutive upstream
+	// failures, fail fast with 503 for BREAKER_COOLDOWN seconds (0 disables).
+	breakerThreshold := 5
+	if v := os.Getenv("BREAKER_THRESHOLD"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			breakerThreshold = x
+		}
+	}
+	breakerCooldown := 30 * time.Second
+	if v := os.Getenv("BREAKER_COOLDOWN"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			breakerCooldown = time.Duration(x) * time.Second
+		}
+	}
+
+	// Per-request upstream status and latency: ACCESS_LOG=true logs a line
+	// and records the latency histogram, ACCESS_LOG=metrics only records.
+	accessLog, accessMetrics := false, false
+	switch v := os.Getenv("ACCESS_LOG"); v {
+	case "metrics":
+		accessMetrics = true
+	default:
+		if x, err := strconv.ParseBool(v); err == nil {
+			accessLog, accessMetrics = x, x
+		}
+	}
+
+	// Cache cacheable upstream GET responses in Redis, up to
+	// CACHE_MAX_BYTES per response.
+	cacheEnabled := false
+	if v := os.Getenv("CACHE_ENABLED"); v != "" {
+		if x, err := strconv.ParseBool(v); err == nil {
+			cacheEnabled = x
+		}
+	}
+	var cacheMaxBytes int64 = 1 << 20
+	if v := os.Getenv("CACHE_MAX_BYTES"); v != "" {
+		if x, err := strconv.ParseInt(v, 10, 64); err == nil {
+			cacheMaxBytes = x
+		}
+	}
+
+	// Header rewrites, e.g. REQUEST_HEADER_RULES="set Authorization: Bearer …; remove Cookie".
+	requestHeaderRules, err := gateway.ParseHeaderRules(os.Getenv("REQUEST_HEADER_RULES"))
+	if err != nil {
+		logging.Fatal("invalid REQUEST_HEADER_RULES", "err", err)
+	}
+	responseHeaderRules, err := gateway.ParseHeaderRules(os.Getenv("RESPONSE_HEADER_RULES"))
+	if err != nil {
+		logging.Fatal("invalid RESPONSE_HEADER_RULES", "err", err)
+	}
+
+	// gzip text-like responses for clients that accept it.
+	compress := false
+	if v := os.Getenv("COMPRESS"); v != "" {
+		if x, err := strconv.ParseBool(v); err == nil {
+			compress = x
+		}
+	}
+
+	// Reject request bodies over this many bytes with 413; 0 disables.
+	var maxBodyBytes int64
+	if v := os.Getenv("MAX_BODY_BYTES"); v != "" {
+		if x, err := strconv.ParseInt(v, 10, 64); err == nil {
+			maxBodyBytes = x
+		}
+	}
+
+	// Cap requests in flight to the upstream, 503 beyond that; 0 disables.
+	maxConcurrency := 0
+	if v := os.Getenv("MAX_CONCURRENCY"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			maxConcurrency = x
+		}
+	}
+
+	return func(r *gin.Engine, limits middleware.Options) func() {
+		// ── Reverse proxy (ROUTES first, then UPSTREAM_URL) ──────────
+		var transport http.RoundTripper = gateway.NewTransport(transportCfg)
+		if upstreamRetries > 0 {
+			transport = gateway.NewRetryTransport(transport, upstreamRetries)
+		}
+		if breakerThreshold > 0 {
+			// Outside the retries, so one retried request is one outcome.
+			transport = gateway.NewBreakerTransport(transport, breakerThreshold, breakerCooldown)
+		}
+		if accessMetrics {
+			// Outermost, so a retried request is one record.
+			transport = gateway.NewAccessLogTransport(transport, accessLog)
+		}
+
+		var balancers []*gateway.Balancer
+		// newBalancer exits on a malformed upstream, naming the setting
+		// (env var) it came from.
+		newBalancer := func(setting string, urls []string) *gateway.Balancer {
+			b, err := gateway.NewBalancer(urls, transport)
+			if err != nil {
+				logging.Fatal("invalid "+setting, "err", err)
+			}
+			if healthInterval > 0 {
+				b.StartHealthChecks(healthPath, healthInterval)
+			}
+			balancers = append(balancers, b)
+			return b
+		}
+
+		routeHandlers := make(map[string]http.Handler, len(routes))
+		for prefix, u := range routes {
+			slog.Info("route configured", "prefix", prefix, "upstream", u)
+			routeHandlers[prefix] = newBalancer("ROUTES", []string{u})
+		}
+
+		var fallback http.Handler
+		if len(upstreams) > 0 {
+			fallback = newBalancer("UPSTREAM_URL", upstreams)
+		}
+		var router http.Handler = gateway.NewRouter(routeHandlers, fallback)
+		if len(requestHeaderRules) > 0 || len(responseHeaderRules) > 0 {
+			// Innermost, so cached responses are stored already rewritten.
+			slog.Info("header rules configured", "request", len(requestHeaderRules), "response", len(responseHeaderRules))
+			router = (&gateway.HeaderRules{Request: requestHeaderRules, Response: responseHeaderRules}).Handler(router)
+		}
+		if cacheEnabled {
+			if config.RDB == nil {
+				logging.Fatal("CACHE_ENABLED requires the Redis store")
+			}
+			slog.Info("response cache enabled", "max_bytes", cacheMaxBytes)
+			router = gateway.NewCache(config.RDB, cacheMaxBytes).Handler(router)
+		}
+		if compress {
+			// Outside the cache, so cached entries stay uncompressed and serve
+			// every client.
+			slog.Info("response compression enabled")
+			router = gateway.Compress(router)
+		}
+
+		// All other routes: rate-limit first, then forward to upstream.
+		// NoRoute catches all requests that don't match registered routes.
+		var chain []gin.HandlerFunc
+		if maxBodyBytes > 0 {
+			chain = append(chain, middleware.MaxBodySize(maxBodyBytes))
+		}
+		chain = append(chain, middleware.RateLimiterWithOptions(limits))
+		if maxConcurrency > 0 {
+			chain = append(chain, middleware.MaxConcurrency(maxConcurrency))
+		}
+		chain = append(chain, gateway.ProxyHandler(router))
+		r.NoRoute(chain...)
+
+		slog.Info("reverse proxy configured", "upstream", upstreamURL, "routes", len(routes))
+		return func() {
+			for _, b := range balancers {
+				b.Close()
+			}
+		}
+	}
+}
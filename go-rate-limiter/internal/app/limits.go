@@ -0,0 +1,320 @@
+package app
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/audit"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/logging"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/middleware"
+)
+
+// loadSettings reads the rate-limit settings that can change at runtime;
+// Run calls it at startup and again on every reload (SIGHUP or .env
+// changes).
+func loadSettings() middleware.Settings {
+	s := middleware.Settings{
+		Limit:         100,
+		WindowSeconds: 60,
+		Mode:          os.Getenv("RATE_LIMIT_MODE"), // "sliding" (default), "sliding_counter", "fixed", "token_bucket", "leaky_bucket" or "calendar"
+	}
+	if v := os.Getenv("RATE_LIMIT"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			s.Limit = x
+		}
+	}
+	if v := os.Getenv("WINDOW_SECONDS"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			s.WindowSeconds = x
+		}
+	}
+	return s
+}
+
+// limitsFromEnv reads every other rate-limiter setting, shared by both
+// modes. Run fills in Live, Store and the Redis-backed hooks once the
+// store is up. The audit webhook, if any, must be closed on shutdown.
+func limitsFromEnv() (opts middleware.Options, auditWebhook *audit.Webhook) {
+	scope := os.Getenv("RATE_LIMIT_SCOPE") // "per_ip" (default) or "global"
+
+	// Fixed mode: units a client may overshoot RATE_LIMIT by per window.
+	burst := 0
+	if v := os.Getenv("RATE_LIMIT_BURST"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			burst = x
+		}
+	}
+
+	// Calendar mode: the UTC period each window ends with ("hour", "day"
+	// or "month").
+	calendarPeriod := os.Getenv("CALENDAR_PERIOD")
+
+	// Fail open (let traffic through unlimited) when Redis errors.
+	failOpen := false
+	if v := os.Getenv("FAIL_OPEN"); v != "" {
+		if x, err := strconv.ParseBool(v); err == nil {
+			failOpen = x
+		}
+	}
+
+	// Count clients per network instead of per address, so rotating
+	// through an IPv6 /64 (or an IPv4 range) doesn't reset the budget.
+	ipv4Prefix, ipv6Prefix := 32, 128
+	if v := os.Getenv("IPV4_PREFIX"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			ipv4Prefix = x
+		}
+	}
+	if v := os.Getenv("IPV6_PREFIX"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			ipv6Prefix = x
+		}
+	}
+	if ipv4Prefix < 1 || ipv4Prefix > 32 || ipv6Prefix < 1 || ipv6Prefix > 128 {
+		logging.Fatal("invalid IPV4_PREFIX / IPV6_PREFIX: IPv4 must be 1-32, IPv6 1-128", "v4", ipv4Prefix, "v6", ipv6Prefix)
+	}
+	var keyFunc middleware.KeyFunc
+	if ipv4Prefix != 32 || ipv6Prefix != 128 {
+		keyFunc = middleware.IPPrefixKey(ipv4Prefix, ipv6Prefix)
+	}
+
+	// Per-user limits from a JWT: key on JWT_CLAIM (sub by default) of an
+	// HMAC-signed bearer token; requests without a valid one get 401.
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		if keyFunc != nil {
+			logging.Fatal("JWT_SECRET cannot be combined with IPV4_PREFIX / IPV6_PREFIX")
+		}
+		claim := os.Getenv("JWT_CLAIM")
+		if claim == "" {
+			claim = "sub"
+		}
+		keyFunc = middleware.JWTKeyFunc(claim, []byte(secret))
+	}
+
+	// Store and log identifiers only as salted SHA-256 hashes (GDPR).
+	hashKeys := false
+	if v := os.Getenv("HASH_KEYS"); v != "" {
+		if x, err := strconv.ParseBool(v); err == nil {
+			hashKeys = x
+		}
+	}
+
+	// Per-user limits behind an authenticating proxy: key on this header
+	// (e.g. X-User-ID) when it comes from one of TRUSTED_PROXIES.
+	identityHeader := os.Getenv("IDENTITY_HEADER")
+
+	// Multi-tenant hosting: count each tenant (TENANT_HEADER, e.g.
+	// X-Tenant-ID) separately; values not in TENANTS share "default".
+	var tenantFunc middleware.TenantFunc
+	if h := os.Getenv("TENANT_HEADER"); h != "" {
+		tenantFunc = middleware.HeaderTenant(h, config.EnvList("TENANTS"))
+	}
+
+	// Per-method limits, e.g. METHOD_LIMITS=GET=1000/60,POST=50/60,*=100/60
+	methods, err := middleware.ParseMethodLimits(os.Getenv("METHOD_LIMITS"))
+	if err != nil {
+		logging.Fatal("invalid METHOD_LIMITS", "err", err)
+	}
+	if len(methods) == 0 {
+		methods = nil
+	}
+
+	// Per-country limits, e.g. COUNTRY_LIMITS=CN=10/60,RU=20/60, with
+	// countries resolved from the MaxMind database at GEOIP_DB.
+	countries, err := middleware.ParseCountryLimits(os.Getenv("COUNTRY_LIMITS"))
+	if err != nil {
+		logging.Fatal("invalid COUNTRY_LIMITS", "err", err)
+	}
+	var geoFunc middleware.GeoFunc
+	if len(countries) == 0 {
+		countries = nil
+	} else if path := os.Getenv("GEOIP_DB"); path == "" {
+		logging.Fatal("COUNTRY_LIMITS requires GEOIP_DB")
+	} else if geoFunc, err = middleware.MaxMindGeo(path); err != nil {
+		logging.Fatal("invalid GEOIP_DB", "err", err)
+	}
+
+	// Extra limits enforced alongside RATE_LIMIT, e.g.
+	// RATE_LIMIT_RULES=10/1,5000/86400 for 10/second and 5000/day.
+	rules, err := middleware.ParseRules(os.Getenv("RATE_LIMIT_RULES"))
+	if err != nil {
+		logging.Fatal("invalid RATE_LIMIT_RULES", "err", err)
+	}
+
+	// Paths never rate limited (and their subpaths); unset keeps the
+	// default of /health and /ready.
+	skipPaths := config.EnvList("SKIP_PATHS")
+
+	// IPs / CIDRs that skip rate limiting, or are always rejected with 403.
+	allowList := config.EnvList("ALLOW_LIST")
+	blockList := config.EnvList("BLOCK_LIST")
+
+	// Upper bound on each Redis rate-limit check (ms); FAIL_OPEN applies on timeout.
+	redisTimeout := 200 * time.Millisecond
+	if v := os.Getenv("REDIS_TIMEOUT"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			redisTimeout = time.Duration(x) * time.Millisecond
+		}
+	}
+
+	// Reject clients known to be over their limit from memory for up to
+	// BLOCK_CACHE_MS milliseconds, without a Redis call (0 disables).
+	var blockCache time.Duration
+	if v := os.Getenv("BLOCK_CACHE_MS"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			blockCache = time.Duration(x) * time.Millisecond
+		}
+	}
+
+	// Hold requests over the limit for up to QUEUE_TIMEOUT milliseconds,
+	// waiting for a slot, before rejecting them (0 rejects at once).
+	var queueTimeout time.Duration
+	if v := os.Getenv("QUEUE_TIMEOUT"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			queueTimeout = time.Duration(x) * time.Millisecond
+		}
+	}
+
+	// Per-request logging: "all" (default), "blocks_only" (rejections and
+	// errors) or "none". Covers both the decision log and the access log.
+	logMode := os.Getenv("LOG_MODE")
+
+	// Dry run: log what would be blocked, but let every request through.
+	dryRun := false
+	if v := os.Getenv("RATE_LIMIT_DRY_RUN"); v != "" {
+		if x, err := strconv.ParseBool(v); err == nil {
+			dryRun = x
+		}
+	}
+
+	// Every block decision POSTed as JSON to a webhook (e.g. a SIEM);
+	// AUDIT_BUFFER events are queued, more are dropped.
+	var auditSink audit.Sink
+	if url := os.Getenv("AUDIT_WEBHOOK_URL"); url != "" {
+		auditBuffer := 1000
+		if v := os.Getenv("AUDIT_BUFFER"); v != "" {
+			if x, err := strconv.Atoi(v); err == nil && x > 0 {
+				auditBuffer = x
+			}
+		}
+		auditWebhook = audit.NewWebhook(url, auditBuffer)
+		auditSink = auditWebhook
+	}
+
+	// Retry-After as an HTTP-date instead of seconds, for legacy clients.
+	retryAfterDate := false
+	if v := os.Getenv("RETRY_AFTER_DATE"); v != "" {
+		if x, err := strconv.ParseBool(v); err == nil {
+			retryAfterDate = x
+		}
+	}
+
+	// Status of rejected requests (429 by default), e.g. 503 for clients
+	// that expect it when throttled.
+	rejectStatus := 0
+	if v := os.Getenv("REJECT_STATUS"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			rejectStatus = x
+		}
+	}
+
+	// Refund requests answered with a 5xx, charging only successful ones.
+	chargeOnSuccessOnly := false
+	if v := os.Getenv("CHARGE_ON_SUCCESS_ONLY"); v != "" {
+		if x, err := strconv.ParseBool(v); err == nil {
+			chargeOnSuccessOnly = x
+		}
+	}
+
+	// Refund requests whose client disconnected before they completed.
+	refundOnCancel := false
+	if v := os.Getenv("REFUND_ON_CANCEL"); v != "" {
+		if x, err := strconv.ParseBool(v); err == nil {
+			refundOnCancel = x
+		}
+	}
+
+	// Log a suspicious_client event once an identifier is rejected this
+	// many times within SUSPICIOUS_WINDOW seconds (0 disables tracking).
+	suspiciousThreshold := 0
+	if v := os.Getenv("SUSPICIOUS_THRESHOLD"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			suspiciousThreshold = x
+		}
+	}
+	suspiciousWindow := 60
+	if v := os.Getenv("SUSPICIOUS_WINDOW"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			suspiciousWindow = x
+		}
+	}
+
+	// Ban an identifier with 403 for BAN_DURATION seconds once it is
+	// rejected BAN_THRESHOLD times within SUSPICIOUS_WINDOW (0 disables).
+	banThreshold := 0
+	if v := os.Getenv("BAN_THRESHOLD"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			banThreshold = x
+		}
+	}
+	banDuration := 10 * time.Minute
+	if v := os.Getenv("BAN_DURATION"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			banDuration = time.Duration(x) * time.Second
+		}
+	}
+
+	// Reject new clients once more than UNIQUE_CLIENTS_LIMIT distinct
+	// identifiers reached a route within UNIQUE_CLIENTS_WINDOW seconds
+	// (0 disables the guard).
+	uniqueClients := 0
+	if v := os.Getenv("UNIQUE_CLIENTS_LIMIT"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			uniqueClients = x
+		}
+	}
+	uniqueClientsWindow := 3600
+	if v := os.Getenv("UNIQUE_CLIENTS_WINDOW"); v != "" {
+		if x, err := strconv.Atoi(v); err == nil {
+			uniqueClientsWindow = x
+		}
+	}
+
+	return middleware.Options{
+		Scope:               scope,
+		Burst:               burst,
+		CalendarPeriod:      calendarPeriod,
+		KeyFunc:             keyFunc,
+		HashKeys:            hashKeys,
+		HashSalt:            os.Getenv("HASH_SALT"),
+		IdentityHeader:      identityHeader,
+		IdentityProxies:     config.EnvList("TRUSTED_PROXIES"),
+		TenantFunc:          tenantFunc,
+		Methods:             methods,
+		Countries:           countries,
+		GeoFunc:             geoFunc,
+		Rules:               rules,
+		FailOpen:            failOpen,
+		Timeout:             redisTimeout,
+		BlockCache:          blockCache,
+		QueueTimeout:        queueTimeout,
+		DryRun:              dryRun,
+		LogMode:             logMode,
+		RetryAfterDate:      retryAfterDate,
+		RejectStatus:        rejectStatus,
+		ChargeOnSuccessOnly: chargeOnSuccessOnly,
+		RefundOnCancel:      refundOnCancel,
+		SuspiciousThreshold: suspiciousThreshold,
+		SuspiciousWindow:    suspiciousWindow,
+		BanThreshold:        banThreshold,
+		BanDuration:         banDuration,
+		UniqueClients:       uniqueClients,
+		UniqueClientsWindow: uniqueClientsWindow,
+		SkipPaths:           skipPaths,
+		AllowList:           allowList,
+		BlockList:           blockList,
+		Audit:               auditSink,
+	}, auditWebhook
+}
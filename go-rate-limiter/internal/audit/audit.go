@@ -0,0 +1,143 @@
+// Package audit records block decisions outside GoShield, e.g. for a
+// SIEM. Sinks receive one Event per blocked request and must never slow
+// the request path down: the webhook sink buffers events and drops them
+// when the buffer is full.
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/metrics"
+)
+
+// Reasons a request was blocked, for Event.Reason.
+const (
+	ReasonRateLimit = "rate_limit" // over its rate limit (429)
+	ReasonBan       = "ban"        // temporarily banned (403)
+	ReasonBlockList = "block_list" // IP on the block list (403)
+
+	ReasonUniqueClients = "unique_clients" // new client over the distinct-client limit (429)
+)
+
+// Event is one block decision.
+type Event struct {
+	Time          time.Time `json:"timestamp"`
+	Reason        string    `json:"reason"`
+	KeyHash       string    `json:"key_hash"` // HashKey of the identifier
+	Method        string    `json:"method"`
+	Route         string    `json:"route"`
+	Mode          string    `json:"mode,omitempty"`
+	Limit         int       `json:"limit,omitempty"`
+	WindowSeconds int       `json:"window_seconds,omitempty"`
+	RequestID     string    `json:"request_id,omitempty"`
+}
+
+// Sink receives block events. Emit is called on the request path, so it
+// must return immediately.
+type Sink interface {
+	Emit(e Event)
+}
+
+// HashKey returns the SHA-256 of a rate-limit identifier, so audit logs
+// can correlate a client's events without storing its IP or user ID.
+func HashKey(identifier string) string {
+	sum := sha256.Sum256([]byte(identifier))
+	return hex.EncodeToString(sum[:])
+}
+
+// webhookTimeout bounds each POST to the webhook.
+const webhookTimeout = 5 * time.Second
+
+// Webhook is a Sink that POSTs each event as JSON to a URL from a
+// background goroutine. Events that arrive while its buffer is full are
+// dropped and counted in metrics.AuditEventsDropped.
+type Webhook struct {
+	url    string
+	client *http.Client
+	events chan Event
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewWebhook returns a Webhook posting to url, buffering up to buffer
+// events, and starts its sender.
+func NewWebhook(url string, buffer int) *Webhook {
+	w := &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+		events: make(chan Event, buffer),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Emit implements Sink. It never blocks.
+func (w *Webhook) Emit(e Event) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return
+	}
+	select {
+	case w.events <- e:
+	default:
+		metrics.AuditEventsDropped.Add(1)
+	}
+}
+
+// Close stops accepting events and waits up to timeout for the buffered
+// ones to be sent.
+func (w *Webhook) Close(timeout time.Duration) {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.events)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+	case <-time.After(timeout):
+		slog.Warn("audit webhook closed with events unsent", "pending", len(w.events))
+	}
+}
+
+// run sends events until Close.
+func (w *Webhook) run() {
+	defer close(w.done)
+	for e := range w.events {
+		w.post(e)
+	}
+}
+
+// post sends one event. Failures are logged and the event dropped; the
+// SIEM is expected to tolerate gaps rather than stall the gateway.
+func (w *Webhook) post(e Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		metrics.AuditEventsDropped.Add(1)
+		slog.Warn("audit webhook unreachable, event dropped", "err", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		metrics.AuditEventsDropped.Add(1)
+		slog.Warn("audit webhook rejected event", "status", resp.StatusCode)
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// dotenvKeys records the environment variables the last LoadDotEnv set
+// from the .env file, the counterpart of fileKeys for --config.
+var dotenvKeys = map[string]bool{}
+
+// LoadDotEnv reads the .env file at path and exports its values, skipping
+// any variable already present in the environment — the same precedence
+// as godotenv.Load.
+//
+// LoadDotEnv may be called again to reload the file: variables that came
+// from the file are updated or cleared, real environment variables are
+// not (godotenv.Overload would clobber them).
+func LoadDotEnv(path string) error {
+	env, err := godotenv.Read(path)
+	if err != nil {
+		return err
+	}
+
+	for key := range dotenvKeys {
+		if _, ok := env[key]; !ok {
+			os.Unsetenv(key) // removed from the file since the last load
+			delete(dotenvKeys, key)
+		}
+	}
+	for key, value := range env {
+		if _, set := os.LookupEnv(key); set && !dotenvKeys[key] {
+			continue // the real environment wins
+		}
+		os.Setenv(key, value)
+		dotenvKeys[key] = true
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDotEnvReloadKeepsRealEnv(t *testing.T) {
+	const real, fromFile = "GOSHIELD_TEST_REAL", "GOSHIELD_TEST_FILE"
+	t.Setenv(real, "50")
+	t.Setenv(fromFile, "")
+	os.Unsetenv(fromFile) // t.Setenv restores the original value afterwards
+	t.Cleanup(func() { dotenvKeys = map[string]bool{} })
+
+	path := filepath.Join(t.TempDir(), ".env")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(real + "=10\n" + fromFile + "=30\n")
+	if err := LoadDotEnv(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv(real); got != "50" {
+		t.Fatalf("%s = %q after load, want the real value 50", real, got)
+	}
+	if got := os.Getenv(fromFile); got != "30" {
+		t.Fatalf("%s = %q after load, want 30 from .env", fromFile, got)
+	}
+
+	write(real + "=20\n" + fromFile + "=60\n")
+	if err := LoadDotEnv(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv(real); got != "50" {
+		t.Fatalf("%s = %q after reload, want the real value 50", real, got)
+	}
+	if got := os.Getenv(fromFile); got != "60" {
+		t.Fatalf("%s = %q after reload, want 60 from .env", fromFile, got)
+	}
+
+	write(real + "=20\n")
+	if err := LoadDotEnv(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, set := os.LookupEnv(fromFile); set {
+		t.Fatalf("%s still set after it was removed from .env", fromFile)
+	}
+	if got := os.Getenv(real); got != "50" {
+		t.Fatalf("%s = %q after removal reload, want the real value 50", real, got)
+	}
+}
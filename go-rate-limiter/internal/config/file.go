@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the YAML configuration file accepted by --config. Every field
+// maps to the environment variable named in its comment; a variable set in
+// the environment always overrides the file.
+//
+//	rate_limit:
+//	  limit: 100
+//	  window_seconds: 60
+//	  mode: sliding
+//	  allow_list: [10.0.0.0/8]
+//	store: redis
+//	redis:
+//	  addr: redis:6379
+//	upstreams: [http://app-1:9000, http://app-2:9000]
+//	routes:
+//	  /auth: http://auth:8000
+type Config struct {
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Store     string          `yaml:"store"` // STORE
+	Redis     RedisConfig     `yaml:"redis"`
+
+	Upstreams      []string          `yaml:"upstreams"`       // UPSTREAM_URL
+	Routes         map[string]string `yaml:"routes"`          // ROUTES: path prefix → upstream
+	TrustedProxies []string          `yaml:"trusted_proxies"` // TRUSTED_PROXIES
+	Port           int               `yaml:"port"`            // PORT (gateway mode)
+	Headers        HeadersConfig     `yaml:"headers"`         // gateway mode
+}
+
+// HeadersConfig is the headers section of Config: header rules such as
+// "set Authorization: Bearer abc" or "remove Cookie", applied in order.
+type HeadersConfig struct {
+	Request  []string `yaml:"request"`  // REQUEST_HEADER_RULES
+	Response []string `yaml:"response"` // RESPONSE_HEADER_RULES
+}
+
+// RateLimitConfig is the rate_limit section of Config.
+type RateLimitConfig struct {
+	Limit          int      `yaml:"limit"`           // RATE_LIMIT
+	WindowSeconds  int      `yaml:"window_seconds"`  // WINDOW_SECONDS
+	Mode           string   `yaml:"mode"`            // RATE_LIMIT_MODE
+	CalendarPeriod string   `yaml:"calendar_period"` // CALENDAR_PERIOD
+	Scope          string   `yaml:"scope"`           // RATE_LIMIT_SCOPE
+	FailOpen       bool     `yaml:"fail_open"`       // FAIL_OPEN
+	DryRun         bool     `yaml:"dry_run"`         // RATE_LIMIT_DRY_RUN
+	AllowList      []string `yaml:"allow_list"`      // ALLOW_LIST
+	BlockList      []string `yaml:"block_list"`      // BLOCK_LIST
+}
+
+// RedisConfig is the redis section of Config.
+type RedisConfig struct {
+	URL           string   `yaml:"url"`            // REDIS_URL
+	Addr          string   `yaml:"addr"`           // REDIS_ADDR
+	Password      string   `yaml:"password"`       // REDIS_PASSWORD
+	DB            int      `yaml:"db"`             // REDIS_DB
+	ClusterAddrs  []string `yaml:"cluster_addrs"`  // REDIS_CLUSTER_ADDRS
+	SentinelAddrs []string `yaml:"sentinel_addrs"` // REDIS_SENTINEL_ADDRS
+	MasterName    string   `yaml:"master_name"`    // REDIS_MASTER_NAME
+}
+
+// fileKeys records the environment variables the last Load set from the
+// file, so a reload can update them while real environment variables
+// keep taking precedence.
+var fileKeys = map[string]bool{}
+
+// Load reads the YAML file at path and exports its values as environment
+// variables, skipping any variable already set to a non-empty value. The
+// rest of GoShield reads its settings from the environment, so this gives
+// env-over-file precedence everywhere without a second code path.
+//
+// Load may be called again to reload the file: variables that came from
+// the file are updated or cleared, real environment variables are not.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true) // reject typos instead of silently ignoring them
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	env := cfg.env()
+	for key := range fileKeys {
+		if _, ok := env[key]; !ok {
+			os.Unsetenv(key) // removed from the file since the last Load
+			delete(fileKeys, key)
+		}
+	}
+	for key, value := range env {
+		if os.Getenv(key) != "" && !fileKeys[key] {
+			continue // the real environment wins; empty counts as unset
+		}
+		os.Setenv(key, value)
+		fileKeys[key] = true
+	}
+
+	return &cfg, nil
+}
+
+// env returns the environment variables cfg sets. Zero values are left
+// out, so they fall back to the usual defaults.
+func (cfg *Config) env() map[string]string {
+	env := map[string]string{}
+	put := func(key, value string) {
+		if value != "" {
+			env[key] = value
+		}
+	}
+	putInt := func(key string, value int) {
+		if value != 0 {
+			env[key] = strconv.Itoa(value)
+		}
+	}
+	putBool := func(key string, value bool) {
+		if value {
+			env[key] = "true"
+		}
+	}
+
+	rl := cfg.RateLimit
+	putInt("RATE_LIMIT", rl.Limit)
+	putInt("WINDOW_SECONDS", rl.WindowSeconds)
+	put("RATE_LIMIT_MODE", rl.Mode)
+	put("CALENDAR_PERIOD", rl.CalendarPeriod)
+	put("RATE_LIMIT_SCOPE", rl.Scope)
+	putBool("FAIL_OPEN", rl.FailOpen)
+	putBool("RATE_LIMIT_DRY_RUN", rl.DryRun)
+	put("ALLOW_LIST", strings.Join(rl.AllowList, ","))
+	put("BLOCK_LIST", strings.Join(rl.BlockList, ","))
+
+	put("STORE", cfg.Store)
+	put("REDIS_URL", cfg.Redis.URL)
+	put("REDIS_ADDR", cfg.Redis.Addr)
+	put("REDIS_PASSWORD", cfg.Redis.Password)
+	putInt("REDIS_DB", cfg.Redis.DB)
+	put("REDIS_CLUSTER_ADDRS", strings.Join(cfg.Redis.ClusterAddrs, ","))
+	put("REDIS_SENTINEL_ADDRS", strings.Join(cfg.Redis.SentinelAddrs, ","))
+	put("REDIS_MASTER_NAME", cfg.Redis.MasterName)
+
+	put("UPSTREAM_URL", strings.Join(cfg.Upstreams, ","))
+	put("TRUSTED_PROXIES", strings.Join(cfg.TrustedProxies, ","))
+	putInt("PORT", cfg.Port)
+	put("REQUEST_HEADER_RULES", strings.Join(cfg.Headers.Request, ";"))
+	put("RESPONSE_HEADER_RULES", strings.Join(cfg.Headers.Response, ";"))
+
+	// ROUTES is "/a=http://x,/b=http://y"; sort for a stable value.
+	routes := make([]string, 0, len(cfg.Routes))
+	for prefix, upstream := range cfg.Routes {
+		routes = append(routes, prefix+"="+upstream)
+	}
+	sort.Strings(routes)
+	put("ROUTES", strings.Join(routes, ","))
+
+	return env
+}
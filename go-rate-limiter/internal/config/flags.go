@@ -0,0 +1,41 @@
+package config
+
+import (
+	"flag"
+	"os"
+)
+
+// EnvFlag is a command-line flag that overrides an environment variable,
+// e.g. --limit for RATE_LIMIT.
+type EnvFlag struct {
+	Name  string // flag name, without dashes
+	Env   string // environment variable it overrides
+	Usage string
+}
+
+// BindEnvFlags defines a string flag on the default command line for
+// each of flags. Call it before flag.Parse.
+//
+// The returned function copies every flag given on the command line into
+// its environment variable, so the rest of startup reads one source and
+// the precedence is flags > environment (and config file) > defaults.
+// Call it right after flag.Parse, before config.Load — the file never
+// overrides the environment — and again after reloading .env, which
+// would otherwise overwrite the flags' values.
+func BindEnvFlags(flags []EnvFlag) func() {
+	values := make([]*string, len(flags))
+	for i, f := range flags {
+		values[i] = flag.String(f.Name, "", f.Usage+" (overrides "+f.Env+")")
+	}
+
+	return func() {
+		set := map[string]bool{}
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+		for i, f := range flags {
+			if set[f.Name] {
+				os.Setenv(f.Env, *values[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// SetTrustedProxies configures which immediate peers r trusts to report
+// the real client IP in X-Forwarded-For / X-Real-IP, from the
+// comma-separated IPs / CIDRs in TRUSTED_PROXIES (e.g. the load balancer's
+// subnet).
+//
+// When TRUSTED_PROXIES is unset no proxy is trusted and c.ClientIP() is
+// always the TCP peer address, so clients cannot spoof their IP (and
+// escape their rate limit) with a forged X-Forwarded-For header.
+func SetTrustedProxies(r *gin.Engine) {
+	proxies := splitList(os.Getenv("TRUSTED_PROXIES"))
+
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		logging.Fatal("invalid TRUSTED_PROXIES", "err", err)
+	}
+	if len(proxies) > 0 {
+		slog.Info("trusting proxy headers", "proxies", proxies)
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce coalesces the burst of events a single save produces
+// (editors often write, rename and chmod in quick succession).
+const reloadDebounce = 200 * time.Millisecond
+
+// WatchReload calls reload whenever the process receives SIGHUP or, when
+// path is not empty, the file at path is written, created or replaced.
+// The file's directory is watched rather than the file itself, so atomic
+// saves (write to a temp file, rename over the original) are seen too.
+// A missing file or watcher error disables file watching, not SIGHUP.
+//
+// reload runs on a single background goroutine, never concurrently with
+// itself. Call the returned function to stop watching.
+func WatchReload(path string, reload func()) (stop func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	var watcher *fsnotify.Watcher
+	if path != "" {
+		w, err := watchFile(path)
+		if err != nil {
+			slog.Warn("config file watch disabled, reload with SIGHUP", "path", path, "err", err)
+		} else {
+			watcher, events, errs = w, w.Events, w.Errors
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+				slog.Info("SIGHUP received, reloading config")
+				reload()
+			case ev := <-events:
+				if filepath.Clean(ev.Name) == filepath.Clean(path) &&
+					ev.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+					debounce = time.After(reloadDebounce)
+				}
+			case err := <-errs:
+				if err != nil {
+					slog.Warn("config file watch error", "path", path, "err", err)
+				}
+			case <-debounce:
+				debounce = nil
+				slog.Info("config file changed, reloading config", "path", path)
+				reload()
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(hup)
+		close(done)
+		if watcher != nil {
+			watcher.Close()
+		}
+	}
+}
+
+// watchFile starts watching the directory containing path.
+func watchFile(path string) (*fsnotify.Watcher, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return w, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/logging"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+)
+
+// NewStore returns the rate-limit backend selected by the STORE env var:
+//   - "redis" (default): connects to Redis and shares counters across instances;
+//     with REDIS_SHARDS, spreads them over several standalone Redis instances;
+//     with REDIS_ADDR_SECONDARY, also mirrors every write to a second Redis
+//   - "memory":          in-process counters for single-instance deployments
+func NewStore() ratelimiter.Store {
+	switch store := os.Getenv("STORE"); store {
+	case "memory":
+		slog.Info("using in-memory store (counters are local to this instance)")
+		return ratelimiter.NewMemoryStore()
+	case "", "redis":
+		var primary ratelimiter.Store
+		if shards := EnvList("REDIS_SHARDS"); len(shards) > 0 {
+			primary = ConnectRedisShards(shards)
+		} else {
+			ConnectRedis()
+			primary = ratelimiter.NewRedisStore(RDB).WithTTLJitter(ttlJitter())
+		}
+		if addr := os.Getenv("REDIS_ADDR_SECONDARY"); addr != "" {
+			return ratelimiter.NewDualStore(primary, ConnectRedisSecondary(addr), mirrorFailed)
+		}
+		return primary
+	default:
+		logging.Fatal(`unknown STORE: use "redis" or "memory"`, "store", store)
+		return nil
+	}
+}
+
+// StoreType returns the backend NewStore selects: "redis" or "memory".
+func StoreType() string {
+	if os.Getenv("STORE") == "memory" {
+		return "memory"
+	}
+	return "redis"
+}
+
+// ttlJitter reads TTL_JITTER: the fraction (0 to 0.5, e.g. 0.1 for ±10%)
+// by which fixed-window TTLs are spread. Unset or 0 disables jitter.
+func ttlJitter() float64 {
+	v := os.Getenv("TTL_JITTER")
+	if v == "" {
+		return 0
+	}
+
+	jitter, err := strconv.ParseFloat(v, 64)
+	if err != nil || jitter < 0 || jitter > 0.5 {
+		logging.Fatal("invalid TTL_JITTER: must be a fraction between 0 and 0.5", "value", v)
+	}
+	return jitter
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/logging"
+)
+
+// redisTLSConfig builds the TLS settings for the Redis connection to addr,
+// or returns nil when TLS is disabled. TLS is on when forceTLS is set
+// (a rediss:// address) or REDIS_TLS is true.
+//
+// Optional settings:
+//   - REDIS_TLS_SKIP_VERIFY: accept any server certificate (self-signed, dev only)
+//   - REDIS_CA_CERT:         path to a PEM CA bundle to verify the server with
+func redisTLSConfig(addr string, forceTLS bool) *tls.Config {
+	if !forceTLS && !envBool("REDIS_TLS") {
+		return nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		cfg.ServerName = host
+	}
+
+	if envBool("REDIS_TLS_SKIP_VERIFY") {
+		slog.Warn("REDIS_TLS_SKIP_VERIFY is set, redis certificate will not be verified")
+		cfg.InsecureSkipVerify = true
+	}
+
+	if path := os.Getenv("REDIS_CA_CERT"); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			logging.Fatal("cannot read REDIS_CA_CERT", "err", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			logging.Fatal("REDIS_CA_CERT contains no valid PEM certificates", "path", path)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg
+}
+
+// envBool reports whether the environment variable key holds a true value
+// ("1", "true", "TRUE", …). Unset or unparsable values are false.
+func envBool(key string) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	return err == nil && v
+}
@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/metrics"
+)
+
+// accessLogTransport is an http.RoundTripper that records every proxied
+// request's upstream status and round-trip time.
+type accessLogTransport struct {
+	next http.RoundTripper
+	log  bool
+}
+
+// NewAccessLogTransport wraps next (http.DefaultTransport when nil) so
+// each request sent upstream is observed in metrics.UpstreamLatency by
+// status class and, with log set, written as one structured log line:
+// method, path, upstream, status and latency. Wrap it around the retry
+// and breaker transports to get one record per proxied request, with
+// retries included in its latency. Responses served from the cache never
+// reach the transport and are not recorded.
+func NewAccessLogTransport(next http.RoundTripper, log bool) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &accessLogTransport{next: next, log: log}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *accessLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+
+	status, class := 0, "error"
+	if err == nil {
+		status = resp.StatusCode
+		class = strconv.Itoa(status/100) + "xx"
+	}
+	metrics.UpstreamLatency.Observe(class, latency.Seconds())
+
+	if t.log {
+		attrs := []any{
+			"request_id", req.Header.Get("X-Request-ID"),
+			"method", req.Method,
+			"path", req.URL.Path,
+			"upstream", req.URL.Host,
+			"status", status,
+			"latency_ms", float64(latency.Microseconds()) / 1000,
+		}
+		if err != nil {
+			attrs = append(attrs, "err", err)
+		}
+		slog.InfoContext(req.Context(), "upstream request", attrs...)
+	}
+	return resp, err
+}
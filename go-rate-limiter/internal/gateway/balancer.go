@@ -0,0 +1,139 @@
+package gateway
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Upstream Balancer — Round-Robin Over Healthy Backends
+// ────────────────────────────────────────────────────────────────────────
+//
+//   request ──► next upstream (round-robin) ──► skip if marked down
+//                                          └──► 503 if every upstream is down
+//
+// A background loop GETs <upstream><path> on every upstream each interval
+// and marks it up on a 2xx response, down otherwise. Upstreams start out
+// up, so traffic flows before the first check completes.
+// ────────────────────────────────────────────────────────────────────────
+
+// healthCheckTimeout bounds a single health-check request.
+const healthCheckTimeout = 2 * time.Second
+
+// Balancer is an http.Handler that spreads requests over several upstreams,
+// skipping the ones whose health check is failing.
+type Balancer struct {
+	upstreams []*upstream
+	next      atomic.Uint64
+	client    *http.Client
+	done      chan struct{}
+	once      sync.Once
+}
+
+// upstream is one backend behind the balancer.
+type upstream struct {
+	url     string
+	proxy   *httputil.ReverseProxy
+	healthy atomic.Bool
+}
+
+// NewBalancer returns a Balancer with one reverse proxy per upstream URL,
+// all sharing transport (http.DefaultTransport when nil). Health checks
+// are off until StartHealthChecks is called. It fails if any URL is not a
+// valid http or https upstream.
+func NewBalancer(upstreamURLs []string, transport http.RoundTripper) (*Balancer, error) {
+	b := &Balancer{
+		client: &http.Client{Timeout: healthCheckTimeout},
+		done:   make(chan struct{}),
+	}
+	for _, raw := range upstreamURLs {
+		proxy, err := NewReverseProxy(raw, transport)
+		if err != nil {
+			return nil, err
+		}
+		u := &upstream{url: strings.TrimSuffix(raw, "/"), proxy: proxy}
+		u.healthy.Store(true)
+		b.upstreams = append(b.upstreams, u)
+	}
+	return b, nil
+}
+
+// ServeHTTP forwards r to the next healthy upstream, or responds 503 when
+// none is healthy.
+func (b *Balancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := uint64(len(b.upstreams))
+	start := b.next.Add(1)
+
+	for i := uint64(0); i < n; i++ {
+		u := b.upstreams[(start+i)%n]
+		if u.healthy.Load() {
+			u.proxy.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write([]byte(`{"error":"no healthy upstream"}`))
+}
+
+// StartHealthChecks probes every upstream at path once per interval in the
+// background until Close is called.
+func (b *Balancer) StartHealthChecks(path string, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			b.checkAll(path)
+			select {
+			case <-b.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stops the health-check loop. The balancer keeps serving with the
+// last known upstream states.
+func (b *Balancer) Close() {
+	b.once.Do(func() { close(b.done) })
+}
+
+// checkAll probes every upstream concurrently and records the outcome,
+// logging each up / down transition.
+func (b *Balancer) checkAll(path string) {
+	var wg sync.WaitGroup
+	for _, u := range b.upstreams {
+		wg.Add(1)
+		go func(u *upstream) {
+			defer wg.Done()
+
+			healthy := b.probe(u.url + path)
+			if u.healthy.Swap(healthy) != healthy {
+				if healthy {
+					slog.Info("upstream is up", "upstream", u.url)
+				} else {
+					slog.Warn("upstream is down", "upstream", u.url)
+				}
+			}
+		}(u)
+	}
+	wg.Wait()
+}
+
+// probe reports whether a GET to url answers with a 2xx status.
+func (b *Balancer) probe(url string) bool {
+	resp, err := b.client.Get(url)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
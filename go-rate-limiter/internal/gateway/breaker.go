@@ -0,0 +1,170 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Circuit Breaker — Fail Fast While an Upstream Is Down
+// ────────────────────────────────────────────────────────────────────────
+//
+//   closed ──(threshold consecutive failures)──► open
+//   open   ──(cooldown elapsed)───────────────► half-open
+//   half-open ──(trial request succeeds)──────► closed
+//   half-open ──(trial request fails)─────────► open
+//
+// While open, requests to that upstream fail immediately with
+// ErrCircuitOpen (503 to the client) instead of each waiting out the
+// upstream timeouts. In half-open, exactly one trial request is let
+// through; the rest keep failing fast until it reports back.
+//
+// Each upstream host has its own breaker, so one dead backend behind a
+// Balancer or Router does not cut off the others.
+// ────────────────────────────────────────────────────────────────────────
+
+// ErrCircuitOpen is returned for requests to an upstream whose circuit is
+// open. NewReverseProxy's error handler turns it into 503.
+var ErrCircuitOpen = errors.New("upstream circuit open")
+
+// Breaker states.
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// breakerTransport is an http.RoundTripper with a circuit breaker per
+// upstream host.
+type breakerTransport struct {
+	next      http.RoundTripper
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// breaker is the circuit state of one upstream host. Guarded by the
+// transport's mutex.
+type breaker struct {
+	state    int
+	failures int       // consecutive failures while closed
+	openedAt time.Time // when the circuit last opened
+	trial    bool      // half-open: the trial request is in flight
+}
+
+// NewBreakerTransport wraps next (http.DefaultTransport when nil) with a
+// circuit breaker per upstream host: after threshold consecutive failures
+// (connection errors or 502 / 503 / 504) the circuit opens and requests
+// fail fast for cooldown, after which one trial request decides whether
+// it closes again.
+func NewBreakerTransport(next http.RoundTripper, threshold int, cooldown time.Duration) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &breakerTransport{
+		next:      next,
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*breaker),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if !t.allow(host) {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if errors.Is(err, context.Canceled) {
+		// The client gave up; that says nothing about the upstream.
+		t.release(host)
+		return resp, err
+	}
+	t.record(host, upstreamFailed(resp, err))
+	return resp, err
+}
+
+// allow reports whether a request to host may be sent now.
+func (t *breakerTransport) allow(host string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.breakers[host]
+	if b == nil {
+		b = &breaker{}
+		t.breakers[host] = b
+	}
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < t.cooldown {
+			return false
+		}
+		b.state, b.trial = circuitHalfOpen, true
+		slog.Info("upstream circuit half-open, sending trial request", "upstream", host)
+		return true
+	case circuitHalfOpen:
+		if b.trial {
+			return false // one trial at a time
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates host's breaker with the outcome of one request.
+func (t *breakerTransport) record(host string, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.breakers[host]
+	switch {
+	case !failed:
+		if b.state != circuitClosed {
+			slog.Info("upstream circuit closed", "upstream", host)
+		}
+		b.state, b.failures, b.trial = circuitClosed, 0, false
+	case b.state == circuitHalfOpen:
+		b.state, b.openedAt, b.trial = circuitOpen, time.Now(), false
+		slog.Warn("upstream circuit re-opened, trial request failed", "upstream", host, "cooldown", t.cooldown)
+	case b.state == circuitClosed:
+		b.failures++
+		if b.failures >= t.threshold {
+			b.state, b.openedAt = circuitOpen, time.Now()
+			slog.Warn("upstream circuit opened", "upstream", host, "failures", b.failures, "cooldown", t.cooldown)
+		}
+	}
+}
+
+// release frees host's half-open trial slot without recording an
+// outcome, so the next request becomes the trial.
+func (t *breakerTransport) release(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.breakers[host].trial = false
+}
+
+// upstreamFailed reports whether a round trip counts as an upstream
+// failure: no response at all, or a 502 / 503 / 504.
+func upstreamFailed(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
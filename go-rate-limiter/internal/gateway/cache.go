@@ -0,0 +1,273 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Response Cache — Serve Repeated GETs Without the Upstream
+// ────────────────────────────────────────────────────────────────────────
+//
+// Successful GET responses the upstream marks cacheable (Cache-Control:
+// max-age / s-maxage) are stored in Redis, keyed by method + path + query,
+// for as long as the upstream allows. Later identical GETs are answered
+// from Redis; the rate limiter still counts them, since it runs first.
+//
+// The cache is deliberately conservative — it is shared by every client,
+// so anything that could be user-specific is never stored:
+//   • requests with Authorization, Cookie or Upgrade headers bypass it;
+//   • responses with Set-Cookie, Vary, private / no-store / no-cache,
+//     or a body over the size limit are not stored.
+// A client sending Cache-Control: no-cache skips the lookup and refreshes
+// the entry. Redis errors only cost the cache: the request goes upstream.
+// ────────────────────────────────────────────────────────────────────────
+
+// cacheKeyPrefix is the Redis key prefix for cached responses.
+const cacheKeyPrefix = "goshield:cache:"
+
+// cacheTimeout bounds each cache read and write.
+const cacheTimeout = 100 * time.Millisecond
+
+// Cache is an http.Handler middleware caching upstream GET responses in
+// Redis.
+type Cache struct {
+	rdb      redis.Cmdable
+	maxBytes int64 // largest body stored; bigger responses are streamed only
+}
+
+// cachedResponse is the stored form of one response.
+type cachedResponse struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// NewCache returns a Cache storing responses of up to maxBytes in rdb.
+func NewCache(rdb redis.Cmdable, maxBytes int64) *Cache {
+	return &Cache{rdb: rdb, maxBytes: maxBytes}
+}
+
+// Handler returns next wrapped with the cache. Responses carry
+// X-Cache: HIT or MISS, and hits an Age header.
+func (c *Cache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !cacheableRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := cacheKey(r)
+
+		if !hasDirective(r.Header.Get("Cache-Control"), "no-cache") {
+			if resp, ok := c.get(r.Context(), key); ok {
+				writeCached(w, resp)
+				return
+			}
+		}
+
+		// Headers already set (X-RateLimit-*, X-Request-ID, …) describe
+		// this request, not the upstream response, so they aren't stored.
+		own := w.Header().Clone()
+		w.Header().Set("X-Cache", "MISS")
+		rec := &cacheRecorder{ResponseWriter: w, status: http.StatusOK, max: c.maxBytes, own: own}
+		next.ServeHTTP(rec, r)
+
+		if ttl, ok := cacheTTL(rec); ok {
+			c.set(r.Context(), key, &cachedResponse{
+				Status:   rec.status,
+				Header:   rec.header,
+				Body:     rec.body.Bytes(),
+				StoredAt: time.Now(),
+			}, ttl)
+		}
+	})
+}
+
+// get returns the cached response for key, if there is one.
+func (c *Cache) get(ctx context.Context, key string) (*cachedResponse, bool) {
+	ctx, cancel := context.WithTimeout(ctx, cacheTimeout)
+	defer cancel()
+
+	data, err := c.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.Warn("response cache read failed", "err", err)
+		}
+		return nil, false
+	}
+
+	var resp cachedResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		slog.Warn("response cache entry unreadable", "err", err)
+		return nil, false
+	}
+	return &resp, true
+}
+
+// set stores resp under key for ttl.
+func (c *Cache) set(ctx context.Context, key string, resp *cachedResponse, ttl time.Duration) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+
+	// The client already has its response; don't let its cancellation
+	// drop the write.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheTimeout)
+	defer cancel()
+
+	if err := c.rdb.Set(ctx, key, data, ttl).Err(); err != nil {
+		slog.Warn("response cache write failed", "err", err)
+	}
+}
+
+// cacheableRequest reports whether r may be served from, or stored in,
+// the shared cache.
+func cacheableRequest(r *http.Request) bool {
+	return r.Method == http.MethodGet &&
+		r.Header.Get("Authorization") == "" &&
+		r.Header.Get("Cookie") == "" &&
+		r.Header.Get("Upgrade") == "" &&
+		!hasDirective(r.Header.Get("Cache-Control"), "no-store")
+}
+
+// cacheKey builds the Redis key for r from its method, path and query,
+// hashed so arbitrarily long URLs give fixed-size keys.
+func cacheKey(r *http.Request) string {
+	sum := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery))
+	return cacheKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// cacheTTL returns how long the recorded response may be cached, and
+// false when it must not be. It judges the upstream's own headers, not
+// the Vary and Content-Encoding that Compress adds on the way out.
+func cacheTTL(rec *cacheRecorder) (time.Duration, bool) {
+	h := rec.header
+	if rec.status != http.StatusOK || rec.overflow ||
+		h.Get("Set-Cookie") != "" || h.Get("Vary") != "" {
+		return 0, false
+	}
+
+	cc := h.Get("Cache-Control")
+	if hasDirective(cc, "private") || hasDirective(cc, "no-store") || hasDirective(cc, "no-cache") {
+		return 0, false
+	}
+
+	// s-maxage is meant for shared caches like this one; it wins.
+	secs, ok := directiveSeconds(cc, "s-maxage")
+	if !ok {
+		secs, ok = directiveSeconds(cc, "max-age")
+	}
+	if !ok || secs <= 0 {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+// hasDirective reports whether the Cache-Control value cc contains name.
+func hasDirective(cc, name string) bool {
+	_, ok := directive(cc, name)
+	return ok
+}
+
+// directiveSeconds returns the integer value of directive name in cc.
+func directiveSeconds(cc, name string) (int64, bool) {
+	v, ok := directive(cc, name)
+	if !ok {
+		return 0, false
+	}
+	secs, err := strconv.ParseInt(strings.Trim(v, `"`), 10, 64)
+	return secs, err == nil
+}
+
+// directive looks up name in the Cache-Control value cc, returning its
+// value ("" for a bare directive such as no-store).
+func directive(cc, name string) (string, bool) {
+	for _, part := range strings.Split(cc, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// upstreamHeader returns the headers in h that the upstream set: those
+// not already in own before the request was proxied.
+func upstreamHeader(h, own http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, v := range h {
+		if _, ok := own[k]; !ok && k != "X-Cache" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// writeCached replays a cached response.
+func writeCached(w http.ResponseWriter, resp *cachedResponse) {
+	h := w.Header()
+	for k, v := range resp.Header {
+		h[k] = v
+	}
+	h.Set("X-Cache", "HIT")
+	h.Set("Age", strconv.Itoa(int(time.Since(resp.StoredAt).Seconds())))
+	h.Set("Content-Length", strconv.Itoa(len(resp.Body)))
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// cacheRecorder passes a response through to the client while keeping a
+// copy of its headers and body, up to max bytes, for the cache.
+type cacheRecorder struct {
+	http.ResponseWriter
+	status   int
+	own      http.Header // headers set before the upstream was called
+	header   http.Header // the upstream's headers; nil until WriteHeader
+	body     bytes.Buffer
+	max      int64
+	overflow bool // body exceeded max; don't store it
+}
+
+func (r *cacheRecorder) WriteHeader(status int) {
+	if r.header == nil && status >= http.StatusOK {
+		// Taken before passing the status on: writers wrapping the cache
+		// (Compress) edit the headers in WriteHeader.
+		r.header = upstreamHeader(r.Header(), r.own)
+	}
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *cacheRecorder) Write(p []byte) (int, error) {
+	if r.header == nil {
+		r.WriteHeader(http.StatusOK)
+	}
+	if !r.overflow {
+		if int64(r.body.Len()+len(p)) > r.max {
+			r.overflow = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(p)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the client's writer to http.ResponseController, so the
+// proxy can still flush streamed responses.
+func (r *cacheRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
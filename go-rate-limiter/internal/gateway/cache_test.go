@@ -0,0 +1,81 @@
+package gateway
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// With both COMPRESS and CACHE_ENABLED, a cacheable text response must
+// still be cached — the Vary added by gzip is not the upstream's — and
+// served from the cache to gzip and plain clients alike.
+func TestCacheWithCompress(t *testing.T) {
+	body := strings.Repeat(`{"item":"value"}`, 64)
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		io.WriteString(w, body)
+	}))
+	defer upstream.Close()
+
+	gw := newTestGateway(t, upstream.URL, HeaderRules{})
+	// Without transparent decompression, to see what the gateway sends.
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	get := func(encoding string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, gw+"/items", nil)
+		if encoding != "" {
+			req.Header.Set("Accept-Encoding", encoding)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var r io.Reader = resp.Body
+		if resp.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r = gz
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(got)
+	}
+
+	for i, tc := range []struct {
+		encoding string
+		cache    string
+		gzipped  bool
+	}{
+		{"gzip", "MISS", true},
+		{"gzip", "HIT", true},
+		{"", "HIT", false},
+	} {
+		resp, got := get(tc.encoding)
+		if c := resp.Header.Get("X-Cache"); c != tc.cache {
+			t.Errorf("request %d: X-Cache = %q, want %q", i+1, c, tc.cache)
+		}
+		if gz := resp.Header.Get("Content-Encoding") == "gzip"; gz != tc.gzipped {
+			t.Errorf("request %d: gzipped = %v, want %v", i+1, gz, tc.gzipped)
+		}
+		if got != body {
+			t.Errorf("request %d: body differs from the upstream's", i+1)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("upstream called %d times, want 1", n)
+	}
+}
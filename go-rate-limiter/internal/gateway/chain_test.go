@@ -0,0 +1,56 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/middleware"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestGateway serves the full gateway chain in front of upstream, wired
+// as gateway mode wires it with every feature on: the access-log, breaker
+// and retry transports, header rules, the response cache and compression,
+// behind the rate limiter. It returns the gateway's URL.
+func newTestGateway(t *testing.T, upstream string, rules HeaderRules) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var transport http.RoundTripper = NewTransport(TransportConfig{DialTimeout: time.Second})
+	transport = NewRetryTransport(transport, 2)
+	transport = NewBreakerTransport(transport, 5, time.Minute)
+	transport = NewAccessLogTransport(transport, false)
+
+	balancer, err := NewBalancer([]string{upstream}, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(balancer.Close)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	var router http.Handler = NewRouter(nil, balancer)
+	router = rules.Handler(router)
+	router = NewCache(rdb, 1<<20).Handler(router)
+	router = Compress(router)
+
+	store := ratelimiter.NewMemoryStore()
+	t.Cleanup(store.Close)
+
+	r := gin.New()
+	r.NoRoute(
+		middleware.RateLimiterWithOptions(middleware.Options{Limit: 100, WindowSeconds: 60, Store: store}),
+		ProxyHandler(router),
+	)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
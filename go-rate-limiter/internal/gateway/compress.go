@@ -0,0 +1,160 @@
+package gateway
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Response Compression — gzip Upstream Responses for Clients That Accept It
+// ────────────────────────────────────────────────────────────────────────
+//
+// The decision is made when the upstream's headers arrive: the response
+// is gzipped on the way to the client only if
+//   • the client sent Accept-Encoding: gzip,
+//   • the upstream did not encode it already (Content-Encoding unset),
+//   • its Content-Type is text-like (text/*, JSON, XML, JavaScript, SVG) —
+//     images, archives and video are already compressed,
+//   • it has a body worth compressing (not HEAD / 204 / 304, and not a
+//     Content-Length under minCompressBytes),
+//   • it is not a protocol upgrade (WebSocket), whose stream is spliced
+//     straight through.
+// Everything else streams through untouched. Compressed responses lose
+// their Content-Length (the new length is unknown up front) and gain
+// Vary: Accept-Encoding so shared caches keep the two forms apart.
+// ────────────────────────────────────────────────────────────────────────
+
+// minCompressBytes is the smallest declared body worth compressing; below
+// it the gzip header and trailer outweigh the saving.
+const minCompressBytes = 256
+
+// gzipWriters recycles gzip writers, whose buffers are large.
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
+
+// Compress wraps next so text-like responses are gzipped for clients that
+// accept it.
+func Compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" ||
+			!acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding value allows gzip.
+func acceptsGzip(ae string) bool {
+	for _, part := range strings.Split(ae, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		return !ok || strings.Trim(q, "0.") != "" // q=0 means "not acceptable"
+	}
+	return false
+}
+
+// compressible reports whether a response with these headers and status
+// should be gzipped.
+func compressible(h http.Header, status int) bool {
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < minCompressBytes {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-javascript", "application/graphql", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// compressWriter gzips the response body once WriteHeader has decided it
+// should.
+type compressWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer // nil: body passes through
+	wroteHeader bool
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	h := w.Header()
+	if compressible(h, status) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		h.Add("Vary", "Accept-Encoding")
+
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends what has been compressed so far, so streamed responses
+// (e.g. server-sent events) still reach the client promptly.
+func (w *compressWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the client's writer to http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close finishes the gzip stream and returns the writer to the pool.
+func (w *compressWriter) close() {
+	if w.gz == nil {
+		return
+	}
+	w.gz.Close()
+	w.gz.Reset(io.Discard)
+	gzipWriters.Put(w.gz)
+	w.gz = nil
+}
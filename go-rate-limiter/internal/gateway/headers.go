@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Header Rules — Rewrite Headers on Their Way Through the Proxy
+// ────────────────────────────────────────────────────────────────────────
+//
+// Request rules run on each request just before the proxy forwards it,
+// so they can inject upstream credentials (set Authorization: Bearer …)
+// or strip client headers the upstream must not see (remove Cookie).
+// Response rules run when the upstream's headers arrive, before anything
+// is sent to the client (remove Server, set Strict-Transport-Security …).
+//
+// Rules are written "<op> <Name>[: <value>]" and applied in order:
+//   set     replaces every value of Name
+//   add     appends a value, keeping existing ones
+//   remove  deletes Name
+// REQUEST_HEADER_RULES / RESPONSE_HEADER_RULES hold them ';'-separated.
+// ────────────────────────────────────────────────────────────────────────
+
+// Header rule operations.
+const (
+	HeaderSet    = "set"
+	HeaderAdd    = "add"
+	HeaderRemove = "remove"
+)
+
+// HeaderRule is one header rewrite.
+type HeaderRule struct {
+	Op    string // HeaderSet, HeaderAdd or HeaderRemove
+	Name  string // canonical header name
+	Value string // unused for HeaderRemove
+}
+
+// HeaderRules rewrites proxied request and response headers.
+type HeaderRules struct {
+	Request  []HeaderRule
+	Response []HeaderRule
+}
+
+// ParseHeaderRules parses a REQUEST_HEADER_RULES / RESPONSE_HEADER_RULES
+// value such as "set Authorization: Bearer abc; remove Cookie".
+func ParseHeaderRules(spec string) ([]HeaderRule, error) {
+	var rules []HeaderRule
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		op, rest, _ := strings.Cut(entry, " ")
+		name, value, hasValue := strings.Cut(rest, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		op = strings.ToLower(op)
+
+		valid := name != "" && !strings.ContainsAny(name, " \t")
+		switch op {
+		case HeaderSet, HeaderAdd:
+			valid = valid && hasValue
+		case HeaderRemove:
+			valid = valid && !hasValue
+		default:
+			valid = false
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid header rule %q: want \"set|add Name: value\" or \"remove Name\"", entry)
+		}
+		rules = append(rules, HeaderRule{Op: op, Name: textproto.CanonicalMIMEHeaderKey(name), Value: value})
+	}
+	return rules, nil
+}
+
+// applyHeaderRules rewrites h according to rules, in order.
+func applyHeaderRules(h http.Header, rules []HeaderRule) {
+	for _, r := range rules {
+		switch r.Op {
+		case HeaderSet:
+			h.Set(r.Name, r.Value)
+		case HeaderAdd:
+			h.Add(r.Name, r.Value)
+		case HeaderRemove:
+			h.Del(r.Name)
+		}
+	}
+}
+
+// Handler returns next wrapped with the header rules.
+func (hr *HeaderRules) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(hr.Request) > 0 {
+			// Rewrite a copy: the client's headers stay as received for
+			// the rest of the middleware chain and the logs.
+			r = r.Clone(r.Context())
+			applyHeaderRules(r.Header, hr.Request)
+		}
+		if len(hr.Response) > 0 {
+			w = &headerRuleWriter{ResponseWriter: w, rules: hr.Response}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// headerRuleWriter applies response rules just before the status line
+// is written.
+type headerRuleWriter struct {
+	http.ResponseWriter
+	rules       []HeaderRule
+	wroteHeader bool
+}
+
+func (w *headerRuleWriter) WriteHeader(status int) {
+	if !w.wroteHeader && status >= http.StatusOK {
+		w.wroteHeader = true
+		applyHeaderRules(w.Header(), w.rules)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headerRuleWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the client's writer to http.ResponseController, so the
+// proxy can still flush streamed responses and hijack upgrades.
+func (w *headerRuleWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
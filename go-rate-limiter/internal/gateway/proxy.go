@@ -1,24 +1,73 @@
 package gateway
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"time"
 
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/tracing"
 	"github.com/gin-gonic/gin"
 )
 
+// flushInterval is how often buffered response data is flushed to the
+// client, so streamed upstream responses reach it without long stalls.
+const flushInterval = 100 * time.Millisecond
+
+// TransportConfig bounds how long the proxy waits on an upstream, so a
+// hung backend cannot pile up gateway connections.
+type TransportConfig struct {
+	DialTimeout           time.Duration // establishing the TCP connection
+	ResponseHeaderTimeout time.Duration // waiting for the response headers
+	IdleConnTimeout       time.Duration // keeping an idle keep-alive connection
+}
+
+// NewTransport returns a copy of http.DefaultTransport with the timeouts
+// in cfg applied. Zero fields keep the default (no limit for response
+// headers).
+func NewTransport(cfg TransportConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}
+		t.DialContext = dialer.DialContext
+	}
+	if cfg.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	}
+	if cfg.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+
+	return t
+}
+
 // NewReverseProxy creates a reverse proxy that forwards requests to the
-// given upstream URL. It preserves the original request path, query
-// parameters, headers, and body.
-func NewReverseProxy(upstream string) *httputil.ReverseProxy {
-	target, err := url.Parse(upstream)
+// given upstream URL over transport (http.DefaultTransport when nil). It
+// preserves the original request path, query parameters, headers — so the
+// X-Request-ID set by middleware.RequestID reaches the upstream — and
+// body.
+//
+// WebSocket (and other Upgrade) requests pass straight through: the
+// Connection: Upgrade and Upgrade headers are forwarded, and on a 101
+// response the client connection is hijacked and spliced to the upstream
+// for bidirectional streaming. The rate limiter counts the handshake once.
+//
+// It returns an error when upstream is not an absolute http:// or https://
+// URL, leaving the caller to decide whether that is fatal.
+func NewReverseProxy(upstream string, transport http.RoundTripper) (*httputil.ReverseProxy, error) {
+	target, err := parseUpstream(upstream)
 	if err != nil {
-		log.Fatalf("❌ Invalid UPSTREAM_URL: %v", err)
+		return nil, err
 	}
 
 	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = transport
+	proxy.FlushInterval = flushInterval
 
 	// Customise the Director to rewrite the request for the upstream.
 	originalDirector := proxy.Director
@@ -29,18 +78,65 @@ func NewReverseProxy(upstream string) *httputil.ReverseProxy {
 
 	// Log proxy errors instead of crashing.
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-		log.Printf("⚠️  Proxy error: %v", err)
+		// The body outgrew middleware.MaxBodySize while being forwarded.
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write([]byte(`{"error":"request body too large"}`))
+			return
+		}
+
+		// The upstream's circuit breaker is open: fail fast with 503.
+		if errors.Is(err, ErrCircuitOpen) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"upstream unavailable"}`))
+			return
+		}
+
+		// r is the outgoing request: the Director has already pointed it
+		// at the upstream, so r.URL.Host is the host that failed.
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"upstream", r.URL.Host,
+			"err", err,
+		}
+		if id := r.Header.Get("X-Request-ID"); id != "" {
+			attrs = append(attrs, "request_id", id)
+		}
+		slog.Warn("proxy error", attrs...)
 		w.WriteHeader(http.StatusBadGateway)
 		w.Write([]byte(`{"error":"bad gateway"}`))
 	}
 
-	return proxy
+	return proxy, nil
+}
+
+// parseUpstream parses an upstream URL, which must be absolute http or
+// https with a host: url.Parse accepts "localhost:9000" (scheme
+// "localhost") or "http//api", which would only fail on the first request.
+func parseUpstream(raw string) (*url.URL, error) {
+	target, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream URL %q: %w", raw, err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("invalid upstream URL %q: scheme must be http or https", raw)
+	}
+	if target.Host == "" {
+		return nil, fmt.Errorf("invalid upstream URL %q: missing host", raw)
+	}
+	return target, nil
 }
 
 // ProxyHandler returns a Gin handler that forwards every request to the
-// upstream through the given reverse proxy.
-func ProxyHandler(proxy *httputil.ReverseProxy) gin.HandlerFunc {
+// upstream through the given reverse proxy (or Balancer).
+func ProxyHandler(proxy http.Handler) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		span := tracing.StartProxy(c) // nil when tracing is off
 		proxy.ServeHTTP(c.Writer, c.Request)
+		tracing.EndProxy(span, c.Writer.Status())
 	}
 }
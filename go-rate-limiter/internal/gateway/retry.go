@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// retryBaseBackoff is the wait before the first retry; it doubles on each
+// further attempt (100ms, 200ms, 400ms, …).
+const retryBaseBackoff = 100 * time.Millisecond
+
+// retryTransport is an http.RoundTripper that retries idempotent requests
+// when the upstream is unreachable or answers 502 / 503, e.g. while a
+// backend restarts.
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+}
+
+// NewRetryTransport wraps next (http.DefaultTransport when nil) so GET,
+// HEAD and OPTIONS requests are retried up to retries times with
+// exponential backoff on connection errors and 502 / 503 responses.
+// Other methods — POST, PUT, PATCH, DELETE — are never retried, since
+// replaying them could apply a change twice.
+func NewRetryTransport(next http.RoundTripper, retries int) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &retryTransport{next: next, retries: retries}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	backoff := retryBaseBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt == t.retries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		slog.Warn("retrying upstream request",
+			"method", req.Method, "path", req.URL.Path, "attempt", attempt+1, "err", err)
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether req may safely be sent more than once: an
+// idempotent method without a body that would be consumed by the first
+// attempt.
+func retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return req.Body == nil || req.Body == http.NoBody
+	default:
+		return false
+	}
+}
+
+// shouldRetry reports whether an attempt failed in a way worth retrying.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable
+}
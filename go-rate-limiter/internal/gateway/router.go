@@ -0,0 +1,84 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/pathutil"
+)
+
+// Router is an http.Handler that sends each request to the upstream whose
+// path prefix matches it, e.g. /auth/* to the auth service and /billing/*
+// to billing. The longest matching prefix wins; requests matching no
+// prefix go to the fallback, or get 404 when there is none.
+type Router struct {
+	routes   []route
+	fallback http.Handler
+}
+
+// route is one path prefix and the handler serving it.
+type route struct {
+	prefix  string
+	handler http.Handler
+}
+
+// NewRouter returns a Router over routes (path prefix → handler). fallback
+// may be nil.
+func NewRouter(routes map[string]http.Handler, fallback http.Handler) *Router {
+	rt := &Router{fallback: fallback}
+	for prefix, h := range routes {
+		rt.routes = append(rt.routes, route{prefix: prefix, handler: h})
+	}
+
+	// Most specific first; ties broken alphabetically for a stable order.
+	sort.Slice(rt.routes, func(i, j int) bool {
+		if len(rt.routes[i].prefix) != len(rt.routes[j].prefix) {
+			return len(rt.routes[i].prefix) > len(rt.routes[j].prefix)
+		}
+		return rt.routes[i].prefix < rt.routes[j].prefix
+	})
+
+	return rt
+}
+
+// ServeHTTP forwards r to the handler of the longest matching prefix.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, route := range rt.routes {
+		if pathutil.MatchesPrefix(r.URL.Path, route.prefix) {
+			route.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	if rt.fallback != nil {
+		rt.fallback.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"error":"no upstream for path"}`))
+}
+
+// ParseRoutes parses a routing table of the form
+// "/auth=http://auth:8000,/billing=http://billing:8000" into a map of
+// path prefix → upstream URL.
+func ParseRoutes(spec string) (map[string]string, error) {
+	routes := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		prefix, upstream, ok := strings.Cut(entry, "=")
+		prefix, upstream = strings.TrimSpace(prefix), strings.TrimSpace(upstream)
+		if !ok || !strings.HasPrefix(prefix, "/") || upstream == "" {
+			return nil, fmt.Errorf("invalid route %q: want /prefix=http://host:port", entry)
+		}
+		routes[prefix] = upstream
+	}
+	return routes, nil
+}
@@ -0,0 +1,68 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocketThroughGateway(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Gateway") != "goshield" {
+			t.Errorf("request header rule not applied to the handshake")
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			kind, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(kind, msg); err != nil {
+				return
+			}
+		}
+	}))
+	defer upstream.Close()
+
+	gw := newTestGateway(t, upstream.URL, HeaderRules{
+		Request:  []HeaderRule{{Op: HeaderSet, Name: "X-Gateway", Value: "goshield"}},
+		Response: []HeaderRule{{Op: HeaderRemove, Name: "Server"}},
+	})
+
+	// Accept-Encoding would make Compress wrap an ordinary response.
+	header := http.Header{"Accept-Encoding": {"gzip"}}
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(gw, "http")+"/ws", header)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial through the gateway: %v (status %d)", err, status)
+	}
+	defer conn.Close()
+
+	if resp.Header.Get("X-RateLimit-Limit") != "100" {
+		t.Errorf("handshake not counted by the limiter: X-RateLimit-Limit = %q", resp.Header.Get("X-RateLimit-Limit"))
+	}
+
+	for _, msg := range []string{"hello", "again"} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		kind, got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kind != websocket.TextMessage || string(got) != msg {
+			t.Fatalf("echo = %d %q, want text %q", kind, got, msg)
+		}
+	}
+}
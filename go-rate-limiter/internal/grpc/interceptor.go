@@ -0,0 +1,104 @@
+// Package grpc applies a ratelimiter.Limiter to gRPC servers, so one
+// Redis-backed limit can be shared between the HTTP gateway and gRPC
+// services:
+//
+//	srv := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(goshieldgrpc.UnaryServerInterceptor(limiter, goshieldgrpc.PeerIP)),
+//		grpc.ChainStreamInterceptor(goshieldgrpc.StreamServerInterceptor(limiter, goshieldgrpc.PeerIP)),
+//	)
+//
+// Calls over the limit fail with codes.ResourceExhausted.
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// KeyFunc extracts the identifier a call is counted against from its
+// context, e.g. the peer IP or an API key sent as metadata.
+type KeyFunc func(ctx context.Context) string
+
+// PeerIP is a KeyFunc that counts calls per client IP address, taken from
+// the connection's remote address. Behind a proxy that is the proxy's
+// address — key on metadata the proxy sets instead (see MetadataKey).
+func PeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String() // e.g. a Unix socket path
+	}
+	return host
+}
+
+// MetadataKey returns a KeyFunc that counts calls per value of the
+// incoming metadata key (e.g. "x-api-key"), falling back to PeerIP for
+// calls that do not send it.
+func MetadataKey(key string) KeyFunc {
+	return func(ctx context.Context) string {
+		if vals := metadata.ValueFromIncomingContext(ctx, key); len(vals) > 0 && vals[0] != "" {
+			return vals[0]
+		}
+		return PeerIP(ctx)
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor that charges every unary
+// call to limiter under keyFunc's identifier, rejecting calls over the
+// limit with codes.ResourceExhausted.
+func UnaryServerInterceptor(limiter *ratelimiter.Limiter, keyFunc KeyFunc) ggrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *ggrpc.UnaryServerInfo, handler ggrpc.UnaryHandler) (any, error) {
+		if err := check(ctx, limiter, keyFunc, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that charges every new
+// stream to limiter once, when it is opened; messages on an admitted
+// stream are not counted.
+func StreamServerInterceptor(limiter *ratelimiter.Limiter, keyFunc KeyFunc) ggrpc.StreamServerInterceptor {
+	return func(srv any, ss ggrpc.ServerStream, info *ggrpc.StreamServerInfo, handler ggrpc.StreamHandler) error {
+		if err := check(ss.Context(), limiter, keyFunc, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// check runs one rate-limit check and returns the status error to fail
+// the call with, or nil to let it proceed. Over the limit, a retry-after
+// header (seconds) tells the client when to try again in window modes.
+func check(ctx context.Context, limiter *ratelimiter.Limiter, keyFunc KeyFunc, method string) error {
+	id := keyFunc(ctx)
+
+	d, err := limiter.Check(ctx, id, 1)
+	if err != nil {
+		slog.Error("rate limit check failed", "method", method, "id", id, "err", err)
+		return status.Error(codes.Unavailable, "rate limit check failed")
+	}
+	if d.Allowed {
+		return nil
+	}
+
+	slog.Warn("rate limit exceeded", "method", method, "id", id, "count", d.Count, "limit", d.Limit)
+	if d.RetryAfter > 0 {
+		secs := max(1, int64((d.RetryAfter+time.Second-1)/time.Second))
+		ggrpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(secs, 10)))
+	}
+	return status.Errorf(codes.ResourceExhausted, "rate limit exceeded: %d requests per %ds", d.Limit, d.WindowSec)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdmin mounts the admin API on r, guarded by a bearer token:
+//
+//	GET    /admin/ratelimit/<id>  → the stored count and TTL in every mode
+//	GET    /admin/ratelimit/<id>/log → the sliding-window timestamps
+//	DELETE /admin/ratelimit/<id>  → clear them, unblocking the client
+//	GET    /admin/metrics         → expvar counters (see package metrics)
+//	GET    /admin/stats?n=10      → the n heaviest identifiers in each mode
+//
+// <id> is the identifier the KeyFunc produces (the client IP by default);
+// it may contain '/' and ':', so composite keys work too — except that a
+// GET for an id ending in "/log" is taken as the log route. Nothing is
+// mounted when token is empty; otherwise it panics unless store
+// implements ratelimiter.AdminStore.
+func RegisterAdmin(r gin.IRouter, store ratelimiter.Store, token string) {
+	if token == "" {
+		return
+	}
+
+	admin, ok := store.(ratelimiter.AdminStore)
+	if !ok {
+		panic("handlers: the admin API needs a store that supports inspection")
+	}
+
+	g := r.Group("/admin", requireToken(token))
+	inspect := inspectRateLimit(admin)
+	if log, ok := store.(ratelimiter.SlidingLogStore); ok {
+		// gin cannot mount /ratelimit/:id/log beside the catch-all, so the
+		// one GET route picks the handler from the suffix.
+		inspect = withSlidingLog(inspect, slidingLog(log))
+	}
+	g.GET("/ratelimit/*id", inspect)
+	g.DELETE("/ratelimit/*id", resetRateLimit(admin))
+	g.GET("/metrics", gin.WrapH(expvar.Handler()))
+	if stats, ok := store.(ratelimiter.StatsStore); ok {
+		g.GET("/stats", rateLimitStats(stats))
+	}
+
+	slog.Info("admin API enabled", "prefix", "/admin")
+}
+
+// requireToken rejects requests without "Authorization: Bearer <token>"
+// with 401. The comparison is constant-time.
+func requireToken(token string) gin.HandlerFunc {
+	want := []byte("Bearer " + token)
+
+	return func(c *gin.Context) {
+		got := []byte(c.GetHeader("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// inspectRateLimit reports the state stored for the identifier in the path.
+func inspectRateLimit(store ratelimiter.AdminStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimPrefix(c.Param("id"), "/")
+
+		states, err := store.Inspect(c.Request.Context(), id)
+		if err != nil {
+			slog.Error("admin inspect failed", "id", id, "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Redis error"})
+			return
+		}
+
+		keys := make([]gin.H, 0, len(states))
+		for _, s := range states {
+			keys = append(keys, gin.H{
+				"mode":   s.Mode,
+				"count":  s.Count,
+				"ttl_ms": s.TTL.Milliseconds(),
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"id": id, "keys": keys})
+	}
+}
+
+// withSlidingLog routes GETs for "<id>/log" to log and all others to
+// inspect.
+func withSlidingLog(inspect, log gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id, ok := strings.CutSuffix(c.Param("id"), "/log"); ok && id != "" {
+			for i, p := range c.Params {
+				if p.Key == "id" {
+					c.Params[i].Value = id
+				}
+			}
+			log(c)
+			return
+		}
+		inspect(c)
+	}
+}
+
+// slidingLog lists the request timestamps in the sliding window of the
+// identifier in the path, oldest first.
+func slidingLog(store ratelimiter.SlidingLogStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimPrefix(c.Param("id"), "/")
+
+		stamps, err := store.SlidingLog(c.Request.Context(), id)
+		if err != nil {
+			slog.Error("admin sliding log failed", "id", id, "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Redis error"})
+			return
+		}
+
+		timestamps := make([]string, len(stamps))
+		for i, t := range stamps {
+			timestamps[i] = t.UTC().Format(time.RFC3339Nano)
+		}
+		c.JSON(http.StatusOK, gin.H{"id": id, "count": len(stamps), "timestamps": timestamps})
+	}
+}
+
+// resetRateLimit clears the state stored for the identifier in the path.
+func resetRateLimit(store ratelimiter.AdminStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimPrefix(c.Param("id"), "/")
+
+		if err := store.Reset(c.Request.Context(), id); err != nil {
+			slog.Error("admin reset failed", "id", id, "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Redis error"})
+			return
+		}
+
+		slog.Info("rate limit reset by admin", "id", id, "ip", c.ClientIP())
+		c.JSON(http.StatusOK, gin.H{"id": id, "status": "reset"})
+	}
+}
+
+// maxStatsTop caps the n parameter of /admin/stats.
+const maxStatsTop = 1000
+
+// rateLimitStats reports the identifiers using the most of their limit,
+// per mode. n (default 10) is how many to list per mode.
+func rateLimitStats(store ratelimiter.StatsStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n, err := strconv.Atoi(c.DefaultQuery("n", "10"))
+		if err != nil || n <= 0 || n > maxStatsTop {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "n must be between 1 and " + strconv.Itoa(maxStatsTop)})
+			return
+		}
+
+		stats, err := store.Stats(c.Request.Context(), n)
+		if err != nil {
+			slog.Error("admin stats failed", "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Redis error"})
+			return
+		}
+
+		modes := gin.H{}
+		for mode, ids := range stats.Top {
+			top := make([]gin.H, 0, len(ids))
+			for _, id := range ids {
+				top = append(top, gin.H{"id": id.Identifier, "count": id.Count})
+			}
+			modes[mode] = top
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"scanned":   stats.Scanned,
+			"truncated": stats.Truncated,
+			"top":       modes,
+		})
+	}
+}
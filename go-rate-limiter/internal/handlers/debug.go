@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterDebugConfig mounts GET /debug/config on r, guarded by the same
+// bearer token as the admin API. It reports the configuration actually
+// in effect — including changes applied by a hot reload — so a deploy can
+// be checked without reading startup logs:
+//
+//	{"mode":"sliding","limit":100,"window_seconds":60,"store":"redis",
+//	 "redis":{"topology":"single","addr":"redis:6379"}}
+//
+// Redis passwords are never reported. Nothing is mounted when token is
+// empty.
+func RegisterDebugConfig(r gin.IRouter, token string, settings *middleware.LiveSettings) {
+	if token == "" {
+		return
+	}
+
+	r.GET("/debug/config", requireToken(token), func(c *gin.Context) {
+		s := settings.Load()
+		mode := s.Mode
+		if mode == "" {
+			mode = "sliding"
+		}
+
+		resp := gin.H{
+			"mode":           mode,
+			"limit":          s.Limit,
+			"window_seconds": s.WindowSeconds,
+			"store":          config.StoreType(),
+		}
+		if config.StoreType() == "redis" {
+			topology, addr := config.RedisTarget()
+			resp["redis"] = gin.H{"topology": topology, "addr": addr}
+		}
+		c.JSON(http.StatusOK, resp)
+	})
+}
@@ -1,9 +1,52 @@
 package handlers
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// healthPingTimeout bounds the optional Redis ping, keeping the liveness
+// probe fast even when Redis hangs.
+const healthPingTimeout = time.Second
+
+// HealthCheck is the liveness probe: it reports that the process is up
+// and serving, without touching Redis.
+//
+// With ?redis=true it also pings Redis and reports the outcome for
+// dashboards — {"status":"OK","redis":"up","latency_ms":3}, or
+// "redis":"down" / "disabled" (in-memory store) — but still answers 200:
+// a Redis outage is a readiness problem (see ReadyCheck), not a reason to
+// restart the process.
 func HealthCheck(c *gin.Context) {
-	c.JSON(200, gin.H{
+	resp := gin.H{
 		"status": "OK",
-	})
+	}
+
+	if withRedis, _ := strconv.ParseBool(c.Query("redis")); withRedis {
+		if config.RDB == nil {
+			resp["redis"] = "disabled"
+		} else {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), healthPingTimeout)
+			start := time.Now()
+			err := config.PingRedis(ctx)
+			cancel()
+
+			if err != nil {
+				// The probe is unauthenticated: log the error (it can name
+				// Redis addresses) instead of returning it.
+				slog.Warn("health check redis ping failed", "err", err)
+				resp["redis"] = "down"
+			} else {
+				resp["redis"] = "up"
+				resp["latency_ms"] = float64(time.Since(start).Microseconds()) / 1000
+			}
+		}
+	}
+
+	c.JSON(200, resp)
 }
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// readyTimeout bounds the Redis ping so a hung connection fails the probe
+// instead of blocking it.
+const readyTimeout = 2 * time.Second
+
+// ReadyCheck is the readiness probe: it pings Redis and returns 503 when
+// it is unreachable, so the orchestrator stops routing traffic here until
+// the limiter can work again. With the in-memory store (no Redis) the
+// instance is always ready. Unlike HealthCheck (liveness), a failure here
+// never means the process should be restarted.
+func ReadyCheck(c *gin.Context) {
+	if config.RDB != nil {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readyTimeout)
+		defer cancel()
+
+		if err := config.PingRedis(ctx); err != nil {
+			slog.Warn("readiness check failed", "err", err)
+			c.JSON(503, gin.H{
+				"status": "unavailable",
+				"redis":  "unreachable",
+			})
+			return
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"status": "OK",
+	})
+}
@@ -0,0 +1,98 @@
+// Package httpmw applies a ratelimiter.Limiter to plain net/http servers
+// and routers built on them, such as chi:
+//
+//	r := chi.NewRouter()
+//	r.Use(httpmw.RateLimit(limiter, httpmw.ClientIP))
+//
+// Responses carry the same X-RateLimit-* / Retry-After headers and 429
+// body as the Gin middleware; RateLimitStatus picks another status, like
+// Options.RejectStatus there.
+package httpmw
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+)
+
+// KeyFunc extracts the identifier a request is counted against.
+type KeyFunc func(r *http.Request) string
+
+// ClientIP is a KeyFunc that counts requests per client IP, taken from
+// the TCP peer address. Forwarding headers are ignored, since any client
+// can set them; behind a load balancer, put a middleware that validates
+// them (e.g. chi's RealIP, with trusted proxies only) in front.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RateLimit returns middleware that charges every request to limiter
+// under keyFunc's identifier. Requests over the limit get 429 with a JSON
+// body; if the check itself fails (e.g. Redis is down) the request is
+// rejected with 500.
+func RateLimit(limiter *ratelimiter.Limiter, keyFunc KeyFunc) func(http.Handler) http.Handler {
+	return RateLimitStatus(limiter, keyFunc, http.StatusTooManyRequests)
+}
+
+// RateLimitStatus is RateLimit answering requests over the limit with
+// rejectStatus instead of 429, e.g. 503 for clients that expect it when
+// throttled. It panics unless rejectStatus is a 4xx or 5xx code.
+func RateLimitStatus(limiter *ratelimiter.Limiter, keyFunc KeyFunc, rejectStatus int) func(http.Handler) http.Handler {
+	if rejectStatus < 400 || rejectStatus > 599 {
+		panic("httpmw: reject status must be a 4xx or 5xx code, got " + strconv.Itoa(rejectStatus))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := keyFunc(r)
+
+			d, err := limiter.Check(r.Context(), id, 1)
+			if err != nil {
+				slog.Error("rate limit check failed", "id", id, "err", err)
+				writeJSON(w, http.StatusInternalServerError, `{"error":"Redis error"}`)
+				return
+			}
+
+			// Window modes know when they reset; bucket modes have no window.
+			windowed := !d.Reset.IsZero()
+			if windowed {
+				setRateLimitHeaders(w.Header(), d)
+			}
+
+			if !d.Allowed {
+				if windowed {
+					secs := max(1, int64((d.RetryAfter+time.Second-1)/time.Second))
+					w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+				}
+				writeJSON(w, rejectStatus,
+					`{"error":"Too many requests","limit":`+strconv.Itoa(d.Limit)+`,"window_seconds":`+strconv.Itoa(d.WindowSec)+`}`)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setRateLimitHeaders writes the X-RateLimit-* headers, matching the Gin
+// middleware: Remaining is clamped at zero, Reset is Unix epoch seconds.
+func setRateLimitHeaders(h http.Header, d *ratelimiter.Decision) {
+	h.Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.FormatInt(d.Remaining(), 10))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt((d.Reset.UnixMilli()+999)/1000, 10))
+}
+
+// writeJSON writes body as a JSON response with status.
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Structured Logging — log/slog, Text for Humans, JSON for Aggregators
+// ────────────────────────────────────────────────────────────────────────
+//
+//   LOG_FORMAT=text (default)  time=… level=INFO msg="rate limit decision" ip=…
+//   LOG_FORMAT=json            {"time":"…","level":"INFO","msg":"rate limit decision","ip":"…"}
+//
+// LOG_LEVEL selects the minimum level: debug, info (default), warn, error.
+// Every package logs through the slog default logger, so Setup must run
+// before anything else logs.
+// ────────────────────────────────────────────────────────────────────────
+
+// Setup installs the slog default logger described by LOG_FORMAT and
+// LOG_LEVEL. Invalid values fall back to text / info with a warning.
+func Setup() {
+	var level slog.Level
+	levelErr := level.UnmarshalText([]byte(envOr("LOG_LEVEL", "info")))
+	if levelErr != nil {
+		level = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	format := strings.ToLower(envOr("LOG_FORMAT", "text"))
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+
+	if levelErr != nil {
+		slog.Warn("invalid LOG_LEVEL, using info", "value", os.Getenv("LOG_LEVEL"))
+	}
+	if format != "json" && format != "text" {
+		slog.Warn("invalid LOG_FORMAT, using text", "value", os.Getenv("LOG_FORMAT"))
+	}
+}
+
+// Fatal logs msg at error level with the given attributes and exits with
+// status 1. It is the slog counterpart of log.Fatalf for startup errors.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// envOr returns the env var key, or def when it is unset or empty.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"expvar"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// Histogram counts observations into fixed buckets per label, the way a
+// Prometheus histogram does, and publishes them through expvar as
+//
+//	{"2xx": {"buckets": {"0.005": 3, …, "+Inf": 9}, "count": 9, "sum": 0.41}}
+//
+// Bucket counts are cumulative: each holds the observations ≤ its bound.
+type Histogram struct {
+	bounds []float64 // upper bounds, ascending
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+// histogramSeries is one label's counts; counts[i] is the observations in
+// (bounds[i-1], bounds[i]], and the last entry those above every bound.
+type histogramSeries struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram returns a Histogram with the given ascending bucket
+// bounds, published under name.
+func NewHistogram(name string, bounds []float64) *Histogram {
+	h := &Histogram{bounds: bounds, series: make(map[string]*histogramSeries)}
+	expvar.Publish(name, expvar.Func(h.snapshot))
+	return h
+}
+
+// Observe records value under label.
+func (h *Histogram) Observe(label string, value float64) {
+	i := len(h.bounds)
+	for j, bound := range h.bounds {
+		if value <= bound {
+			i = j
+			break
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[label]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.bounds)+1)}
+		h.series[label] = s
+	}
+	s.counts[i]++
+	s.count++
+	s.sum += value
+}
+
+// snapshot returns every label's cumulative buckets, count and sum.
+func (h *Histogram) snapshot() any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make(map[string]any, len(h.series))
+	for label, s := range h.series {
+		buckets := make(map[string]uint64, len(h.bounds)+1)
+		var cumulative uint64
+		for i, n := range s.counts {
+			cumulative += n
+			bound := math.Inf(1)
+			if i < len(h.bounds) {
+				bound = h.bounds[i]
+			}
+			buckets[strconv.FormatFloat(bound, 'g', -1, 64)] = cumulative
+		}
+		out[label] = map[string]any{"buckets": buckets, "count": s.count, "sum": s.sum}
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+// Package metrics holds GoShield's process-wide counters. They are
+// published through the standard library's expvar, so any scraper that
+// reads /debug/vars-style JSON (or the Prometheus expvar exporter) can
+// collect them; the admin API serves them at GET /admin/metrics.
+package metrics
+
+import "expvar"
+
+// SuspiciousClients counts clients that crossed the breach threshold:
+// one increment per client per breach window, not per rejected request.
+var SuspiciousClients = expvar.NewInt("goshield_suspicious_clients_total")
+
+// BannedClients counts bans applied after a client crossed the ban
+// threshold.
+var BannedClients = expvar.NewInt("goshield_banned_clients_total")
+
+// BlockCacheHits counts requests rejected from the in-process block
+// cache without a store round-trip (see middleware.Options.BlockCache).
+var BlockCacheHits = expvar.NewInt("goshield_block_cache_hits_total")
+
+// QueuedAdmits counts requests admitted after waiting in the queue for a
+// slot instead of being rejected (see middleware.Options.QueueTimeout).
+var QueuedAdmits = expvar.NewInt("goshield_queued_admits_total")
+
+// MirrorFailures counts limiter writes that never reached the secondary
+// Redis of a migration (REDIS_ADDR_SECONDARY): failed, timed out or
+// dropped because too many were in flight.
+var MirrorFailures = expvar.NewInt("goshield_mirror_failures_total")
+
+// AuditEventsDropped counts audit events that never reached the sink:
+// its buffer was full, or the webhook failed or rejected them.
+var AuditEventsDropped = expvar.NewInt("goshield_audit_events_dropped_total")
+
+// UpstreamLatency is the round-trip time of proxied requests in seconds,
+// by upstream status class ("2xx" … "5xx", or "error" when no response
+// came back). Recorded with ACCESS_LOG (see gateway.NewAccessLogTransport).
+var UpstreamLatency = NewHistogram("goshield_upstream_latency_seconds",
+	[]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10})
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/audit"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/metrics"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/gin-gonic/gin"
+)
+
+// Defaults for Options.SuspiciousWindow and Options.BanDuration.
+const (
+	defaultSuspiciousWindow = 60
+	defaultBanDuration      = 10 * time.Minute
+)
+
+// newBreachTracker returns the function the limiter calls for every
+// rejected request, or nil when neither breach threshold is set. It
+// counts the rejection and acts once, at the moment the client crosses
+// each threshold, so a sustained attack produces one event per window
+// rather than one per request.
+func newBreachTracker(opts Options) func(c *gin.Context, id string) {
+	if opts.SuspiciousThreshold <= 0 && opts.BanThreshold <= 0 {
+		return nil
+	}
+
+	breaches := opts.Store.(ratelimiter.BreachStore) // checked by Validate
+	var bans ratelimiter.BanStore
+	if opts.BanThreshold > 0 {
+		bans = opts.Store.(ratelimiter.BanStore)
+	}
+
+	window := opts.SuspiciousWindow
+	if window <= 0 {
+		window = defaultSuspiciousWindow
+	}
+	banDuration := opts.BanDuration
+	if banDuration <= 0 {
+		banDuration = defaultBanDuration
+	}
+	suspicious, banAt := int64(opts.SuspiciousThreshold), int64(opts.BanThreshold)
+	slog.Info("breach tracking enabled", "suspicious_threshold", suspicious,
+		"ban_threshold", banAt, "ban_duration", banDuration, "window_seconds", window)
+
+	return func(c *gin.Context, id string) {
+		ctx, cancel := context.WithTimeout(config.Ctx, opts.Timeout)
+		defer cancel()
+
+		count, err := breaches.RecordBreach(ctx, id, window)
+		if err != nil {
+			slog.Warn("recording rate-limit breach failed", "id", id, "err", err)
+			return
+		}
+
+		if count == suspicious {
+			metrics.SuspiciousClients.Add(1)
+			slog.Warn("suspicious_client",
+				"id", id,
+				"ip", logIP(c),
+				"breaches", count,
+				"window_seconds", window,
+				"path", c.Request.URL.Path,
+			)
+		}
+
+		if count != banAt {
+			return
+		}
+		if opts.DryRun {
+			slog.Warn("client would_ban", "id", id, "ip", logIP(c), "breaches", count, "duration", banDuration)
+			return
+		}
+		if err := bans.Ban(ctx, id, banDuration); err != nil {
+			slog.Error("banning client failed", "id", id, "err", err)
+			return
+		}
+		metrics.BannedClients.Add(1)
+		slog.Warn("client banned", "id", id, "ip", logIP(c), "breaches", count, "duration", banDuration)
+	}
+}
+
+// newBanCheck returns the function the limiter runs before every check,
+// or nil when bans are disabled. It rejects a banned client with 403 and
+// reports true; a failed lookup lets the request through to the normal
+// limiter rather than blocking everyone while the store is unhealthy.
+func newBanCheck(opts Options) func(c *gin.Context, id string) bool {
+	if opts.BanThreshold <= 0 || opts.DryRun {
+		return nil
+	}
+	bans := opts.Store.(ratelimiter.BanStore) // checked by newBreachTracker
+
+	return func(c *gin.Context, id string) bool {
+		ctx, cancel := context.WithTimeout(config.Ctx, opts.Timeout)
+		left, err := bans.Banned(ctx, id)
+		cancel()
+		if err != nil {
+			slog.Warn("ban lookup failed", "id", id, "err", err)
+			return false
+		}
+		if left <= 0 {
+			return false
+		}
+
+		setRetryAfter(c, left, opts.RetryAfterDate)
+		c.JSON(http.StatusForbidden, gin.H{"error": "temporarily banned"})
+		c.Abort()
+		auditBlock(c, opts.Audit, audit.ReasonBan, id, "", 0, 0)
+		return true
+	}
+}
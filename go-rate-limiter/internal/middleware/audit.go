@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/audit"
+	"github.com/gin-gonic/gin"
+)
+
+// auditBlock reports a blocked request to sink, if one is configured.
+// mode, limit and windowSeconds are zero for blocks that precede the
+// rate-limit check (bans, the block list).
+func auditBlock(c *gin.Context, sink audit.Sink, reason, id, mode string, limit, windowSeconds int) {
+	if sink == nil {
+		return
+	}
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	sink.Emit(audit.Event{
+		Time:          time.Now().UTC(),
+		Reason:        reason,
+		KeyHash:       audit.HashKey(id),
+		Method:        c.Request.Method,
+		Route:         route,
+		Mode:          mode,
+		Limit:         limit,
+		WindowSeconds: windowSeconds,
+		RequestID:     GetRequestID(c),
+	})
+}
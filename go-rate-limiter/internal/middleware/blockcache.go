@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// blockCacheSweep is how often expired blockCache entries are dropped.
+const blockCacheSweep = 10 * time.Second
+
+// blockCache remembers, in-process, which keys the store has rejected and
+// until when they certainly stay rejected, so a client hammering away
+// while over its limit is turned away without a store round-trip.
+//
+// An entry lasts until the earlier of the rejection's Retry-After and
+// maxAge: the window cannot free up before Retry-After, and maxAge bounds
+// how long a reset via the admin API or a refund goes unnoticed. Only
+// window modes are cached; bucket modes report no Retry-After.
+type blockCache struct {
+	maxAge time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]blockedEntry
+	lastSweep time.Time
+}
+
+// blockedEntry is one cached rejection.
+type blockedEntry struct {
+	result  Result    // the rejection, replayed to later requests
+	until   time.Time // when the entry stops being trusted
+	retryAt time.Time // when the client may retry, for Retry-After
+}
+
+// newBlockCache returns a cache trusting rejections for up to maxAge, or
+// nil when maxAge is not positive.
+func newBlockCache(maxAge time.Duration) *blockCache {
+	if maxAge <= 0 {
+		return nil
+	}
+	return &blockCache{maxAge: maxAge, entries: map[string]blockedEntry{}, lastSweep: time.Now()}
+}
+
+// add records a rejection for key.
+func (b *blockCache) add(key string, result *Result) {
+	if result.RetryAfter <= 0 {
+		return
+	}
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[key] = blockedEntry{
+		result:  *result,
+		until:   now.Add(min(result.RetryAfter, b.maxAge)),
+		retryAt: now.Add(result.RetryAfter),
+	}
+
+	// Attacks spread over many keys leave many entries behind; sweep
+	// them here rather than on every lookup.
+	if now.Sub(b.lastSweep) >= blockCacheSweep {
+		for k, e := range b.entries {
+			if !now.Before(e.until) {
+				delete(b.entries, k)
+			}
+		}
+		b.lastSweep = now
+	}
+}
+
+// get returns the cached rejection for key, with RetryAfter counted down
+// to now, if one is still trusted.
+func (b *blockCache) get(key string) (*Result, bool) {
+	now := time.Now()
+
+	b.mu.Lock()
+	e, ok := b.entries[key]
+	if ok && !now.Before(e.until) {
+		delete(b.entries, key)
+		ok = false
+	}
+	b.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+	result := e.result
+	result.RetryAfter = e.retryAt.Sub(now)
+	return &result, true
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/gin-gonic/gin"
+)
+
+// Rejections answered from the block cache must still count as breaches,
+// or a client hammering away after its first 429 would never be banned,
+// and must still be logged in blocks_only mode.
+func TestBlockCacheStillTracksBreaches(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := ratelimiter.NewMemoryStore()
+	defer store.Close()
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	r := gin.New()
+	r.Use(RateLimiterWithOptions(Options{
+		Limit:         1,
+		WindowSeconds: 60,
+		Mode:          "fixed",
+		Store:         store,
+		BlockCache:    time.Second,
+		BanThreshold:  3,
+		LogMode:       LogBlocksOnly,
+	}))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	// 1 allowed; 2 rejected by the store and cached; 3 and 4 rejected from
+	// the cache, the third breach banning the client; 5 banned.
+	want := []int{
+		http.StatusNoContent,
+		http.StatusTooManyRequests,
+		http.StatusTooManyRequests,
+		http.StatusTooManyRequests,
+		http.StatusForbidden,
+	}
+	for i, status := range want {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != status {
+			t.Fatalf("request %d: status %d, want %d", i+1, w.Code, status)
+		}
+	}
+
+	if n := strings.Count(logs.String(), "allowed=false"); n != 3 {
+		t.Fatalf("%d rejections logged, want 3:\n%s", n, logs.String())
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBodySize returns a middleware that rejects request bodies larger than
+// limit bytes with 413 Request Entity Too Large, so a huge upload never
+// reaches — or exhausts the memory of — the upstream.
+//
+// A declared Content-Length over the limit is rejected up front. Otherwise
+// the body is wrapped in http.MaxBytesReader, which fails the read once
+// limit bytes have been consumed; the gateway proxy turns that failure into
+// the same 413 (see gateway.NewReverseProxy).
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "request body too large",
+			})
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxConcurrency returns a middleware that lets at most n requests run the
+// rest of the chain at once, answering 503 Service Unavailable to any
+// request that arrives while all n slots are taken.
+//
+// Rate limits cap how many requests start per window, not how many are in
+// flight: a hundred slow requests admitted in the same second can still
+// exhaust a backend's small connection pool. The slots form a buffered
+// channel semaphore; a slot is released when the request completes,
+// whether or not a later handler aborted it.
+func MaxConcurrency(n int) gin.HandlerFunc {
+	slots := make(chan struct{}, n)
+
+	return func(c *gin.Context) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "too many concurrent requests",
+			})
+			return
+		}
+		defer func() { <-slots }()
+
+		c.Next()
+	}
+}
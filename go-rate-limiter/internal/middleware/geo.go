@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoFunc resolves the country a request comes from, as an upper-case ISO
+// 3166-1 alpha-2 code ("DE"). ok is false when the country is unknown —
+// private addresses, or IPs missing from the database.
+type GeoFunc func(c *gin.Context) (country string, ok bool)
+
+// geoRecord is the part of a GeoIP2 / GeoLite2 Country or City record
+// MaxMindGeo reads.
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// MaxMindGeo opens the MaxMind database at path (GeoLite2-Country.mmdb or
+// any GeoIP2 Country / City database) and returns a GeoFunc resolving
+// c.ClientIP() against it. The database is memory-mapped and stays open
+// for the life of the process; lookups make no network calls.
+func MaxMindGeo(path string) (GeoFunc, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open GeoIP database: %w", err)
+	}
+	slog.Info("GeoIP database loaded", "path", path, "type", db.Metadata.DatabaseType)
+
+	return func(c *gin.Context) (string, bool) {
+		ip := net.ParseIP(c.ClientIP())
+		if ip == nil {
+			return "", false
+		}
+
+		var rec geoRecord
+		if err := db.Lookup(ip, &rec); err != nil {
+			slog.Debug("GeoIP lookup failed", "err", err)
+			return "", false
+		}
+		return rec.Country.ISOCode, rec.Country.ISOCode != ""
+	}, nil
+}
+
+// countryLimit returns the limit and window for the country c comes from:
+// its entry in countries, or ok is false — unknown country, lookup failed,
+// or no entry — and the limit resolved so far applies.
+func countryLimit(countries map[string]Tier, geo GeoFunc, c *gin.Context) (limit, windowSeconds int, ok bool) {
+	country, ok := geo(c)
+	if !ok {
+		return 0, 0, false
+	}
+	t, ok := countries[country]
+	return t.Limit, t.WindowSeconds, ok
+}
+
+// ParseCountryLimits parses a COUNTRY_LIMITS value such as
+// "CN=10/60,RU=20/60" — country=limit/window_seconds, comma-separated —
+// into Options.Countries. Country codes are upper-cased.
+func ParseCountryLimits(spec string) (map[string]Tier, error) {
+	countries := make(map[string]Tier)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		country, quota, ok := strings.Cut(entry, "=")
+		limit, window, ok2 := strings.Cut(quota, "/")
+		l, err1 := strconv.Atoi(strings.TrimSpace(limit))
+		w, err2 := strconv.Atoi(strings.TrimSpace(window))
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if !ok || !ok2 || err1 != nil || err2 != nil || l < 1 || w < 1 || len(country) != 2 {
+			return nil, fmt.Errorf("invalid country limit %q: want CC=limit/window_seconds", entry)
+		}
+		countries[country] = Tier{Limit: l, WindowSeconds: w}
+	}
+	return countries, nil
+}
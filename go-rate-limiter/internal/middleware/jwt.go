@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwtAlgs maps the HMAC algorithms JWTKeyFunc accepts to their hash. Any
+// other "alg" — notably "none" — is rejected.
+var jwtAlgs = map[string]func() hash.Hash{
+	"HS256": sha256.New,
+	"HS384": sha512.New384,
+	"HS512": sha512.New,
+}
+
+// JWTKeyFunc returns a KeyFunc that counts requests per user: it takes the
+// token from "Authorization: Bearer <jwt>", verifies its HMAC signature
+// (HS256, HS384 or HS512) with secret and its exp / nbf times, and keys
+// the request on claim (e.g. "sub"). Requests without a valid token, or
+// whose token lacks the claim, are aborted with 401 and never counted.
+//
+//	middleware.RateLimiterWithOptions(middleware.Options{
+//		KeyFunc: middleware.JWTKeyFunc("sub", []byte(os.Getenv("JWT_SECRET"))),
+//	})
+//
+// Every request the limiter sees then needs a token, so mount it on the
+// authenticated routes only; SkipPaths are exempt as usual. It panics if
+// claim or secret is empty.
+func JWTKeyFunc(claim string, secret []byte) KeyFunc {
+	if claim == "" || len(secret) == 0 {
+		panic("middleware: JWTKeyFunc needs a claim and a secret")
+	}
+
+	return func(c *gin.Context) string {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return ""
+		}
+
+		id, err := jwtClaim(strings.TrimSpace(token), claim, secret, time.Now())
+		if err != nil {
+			slog.Debug("rejected JWT", "ip", logIP(c), "err", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return ""
+		}
+		return id
+	}
+}
+
+// jwtClaim verifies token against secret at now and returns claim as a
+// string. Numeric claims are returned in their JSON form.
+func jwtClaim(token, claim string, secret []byte, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return "", err
+	}
+	newHash, ok := jwtAlgs[header.Alg]
+	if !ok {
+		return "", errors.New("unsupported alg " + header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("malformed signature")
+	}
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", errors.New("bad signature")
+	}
+
+	var claims map[string]any
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return "", err
+	}
+	if exp, ok := claims["exp"].(json.Number); ok {
+		if t, err := exp.Float64(); err != nil || !now.Before(time.Unix(int64(t), 0)) {
+			return "", errors.New("token expired")
+		}
+	}
+	if nbf, ok := claims["nbf"].(json.Number); ok {
+		if t, err := nbf.Float64(); err != nil || now.Before(time.Unix(int64(t), 0)) {
+			return "", errors.New("token not valid yet")
+		}
+	}
+
+	switch v := claims[claim].(type) {
+	case string:
+		if v != "" {
+			return v, nil
+		}
+	case json.Number:
+		return v.String(), nil
+	}
+	return "", errors.New("no usable " + claim + " claim")
+}
+
+// decodeJWTPart decodes one base64url-encoded JSON segment into v, keeping
+// numbers as json.Number.
+func decodeJWTPart(part string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errors.New("malformed token")
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return errors.New("malformed token")
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompositeKey is a KeyFunc that counts requests per client IP, HTTP
+// method and route, as "ip:method:route", so a burst of POSTs to one
+// endpoint never consumes the GET budget of another.
+//
+// The route is the matched Gin pattern (e.g. "/users/:id") when there is
+// one, and the raw path otherwise. Keys stay unambiguous: the method can
+// never contain ':' or '/', and the route always starts with '/', which no
+// IP address contains.
+//
+// Cardinality: every distinct (ip, method, route) tuple is its own Redis
+// key. With matched patterns that is bounded by clients × routes, but raw
+// paths — e.g. gateway mode, where nothing matches a Gin route — can
+// create a key per resource ID (/users/1, /users/2, …). Wrap it in
+// HashedKey to bound the size of each key, and prefer patterns or
+// RateLimiterForRoutes prefixes when the path space is large.
+func CompositeKey(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	return c.ClientIP() + ":" + c.Request.Method + ":" + route
+}
+
+// HashedKey wraps keyFunc so identifiers longer than maxLen bytes are
+// replaced by their SHA-256 digest ("sha256:<hex>"), keeping Redis keys
+// short whatever the URL length. Shorter identifiers pass through
+// unchanged, so the two forms can never collide.
+func HashedKey(keyFunc KeyFunc, maxLen int) KeyFunc {
+	return func(c *gin.Context) string {
+		id := keyFunc(c)
+		if len(id) <= maxLen {
+			return id
+		}
+		sum := sha256.Sum256([]byte(id))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+}
+
+// IPPrefixKey returns a KeyFunc that counts requests per network rather
+// than per address: the client IP is masked to its first v4Bits (IPv4)
+// or v6Bits (IPv6) bits, so a client rotating through, say, its /64 of
+// IPv6 space still shares one budget. Masked keys are written in CIDR
+// form ("2001:db8:1:2::/64"); a full-length prefix (32 / 128) keeps the
+// plain IP, matching the default key. It panics unless v4Bits is 1-32
+// and v6Bits 1-128.
+func IPPrefixKey(v4Bits, v6Bits int) KeyFunc {
+	if v4Bits < 1 || v4Bits > 32 || v6Bits < 1 || v6Bits > 128 {
+		panic(fmt.Sprintf("middleware: invalid IP prefix length /%d, /%d: IPv4 must be 1-32, IPv6 1-128", v4Bits, v6Bits))
+	}
+	v4Mask, v6Mask := net.CIDRMask(v4Bits, 32), net.CIDRMask(v6Bits, 128)
+	v4Suffix, v6Suffix := "/"+strconv.Itoa(v4Bits), "/"+strconv.Itoa(v6Bits)
+
+	return func(c *gin.Context) string {
+		raw := c.ClientIP()
+		ip := net.ParseIP(raw)
+		switch {
+		case ip == nil:
+			return raw // not an IP (e.g. a Unix socket); nothing to mask
+		case ip.To4() != nil:
+			if v4Bits == 32 {
+				return raw
+			}
+			return ip.To4().Mask(v4Mask).String() + v4Suffix
+		default:
+			if v6Bits == 128 {
+				return raw
+			}
+			return ip.Mask(v6Mask).String() + v6Suffix
+		}
+	}
+}
+
+// maxIdentityLen bounds identity header values used as keys.
+const maxIdentityLen = 128
+
+// identityKey wraps fallback so requests carrying header are counted per
+// its value — the user an authenticating proxy vouches for — as
+// "user:<value>". The header is only believed when the TCP peer is one of
+// proxies, since anyone else could set it to borrow another user's
+// budget; values that are empty, over maxIdentityLen bytes or not
+// printable ASCII are ignored too. Otherwise fallback keys the request.
+func identityKey(header string, proxies []string, fallback KeyFunc) KeyFunc {
+	trusted, _ := parseIPList(proxies) // checked by Options.Validate
+	slog.Info("keying on identity header from trusted proxies", "header", header, "proxies", len(trusted))
+
+	return func(c *gin.Context) string {
+		if !containsIP(trusted, c.RemoteIP()) {
+			return fallback(c)
+		}
+		id := strings.TrimSpace(c.GetHeader(header))
+		if !validIdentity(id) {
+			return fallback(c)
+		}
+		return "user:" + id
+	}
+}
+
+// validIdentity reports whether id is usable as an identity: non-empty,
+// at most maxIdentityLen bytes, printable ASCII without spaces.
+func validIdentity(id string) bool {
+	if id == "" || len(id) > maxIdentityLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Settings is the part of a limiter's configuration that can change while
+// it is serving traffic.
+type Settings struct {
+	Limit         int    // max requests allowed per window
+	WindowSeconds int    // window duration in seconds
+	Mode          string // "fixed", "sliding" (default), "sliding_counter", "token_bucket", "leaky_bucket", "calendar"
+}
+
+// Validate reports settings that cannot limit anything sensibly: a limit
+// of zero or less would reject every request, and a window of zero or
+// less has no meaning. A typo in RATE_LIMIT must not take down all traffic.
+func (s Settings) Validate() error {
+	if s.Limit <= 0 {
+		return fmt.Errorf("rate limit must be positive, got %d", s.Limit)
+	}
+	if s.WindowSeconds <= 0 {
+		return fmt.Errorf("window must be positive, got %d seconds", s.WindowSeconds)
+	}
+	return nil
+}
+
+// LiveSettings holds Settings behind an atomic pointer, so they can be
+// swapped — e.g. to tighten limits during an attack — without restarting
+// or dropping connections. Each request reads the current value once, so
+// it is checked against one consistent set of settings.
+type LiveSettings struct {
+	p atomic.Pointer[Settings]
+}
+
+// NewLiveSettings returns LiveSettings starting out as s.
+func NewLiveSettings(s Settings) *LiveSettings {
+	l := &LiveSettings{}
+	l.p.Store(&s)
+	return l
+}
+
+// Load returns the current settings.
+func (l *LiveSettings) Load() Settings {
+	return *l.p.Load()
+}
+
+// Store replaces the settings; requests that start afterwards use s.
+// Counters are kept, but switching Mode starts every client afresh, as
+// each algorithm keeps its state under its own key. Invalid settings (see
+// Settings.Validate) are logged and ignored, keeping the current ones, so
+// a bad reload cannot block all traffic.
+func (l *LiveSettings) Store(s Settings) {
+	if err := s.Validate(); err != nil {
+		slog.Error("rate limit settings rejected, keeping the current ones", "err", err)
+		return
+	}
+	old := l.p.Swap(&s)
+	if *old != s {
+		slog.Info("rate limit settings reloaded",
+			"mode", modeOrDefault(s.Mode), "limit", s.Limit, "window_seconds", s.WindowSeconds)
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import "testing"
+
+func TestSettingsValidate(t *testing.T) {
+	for _, tc := range []struct {
+		limit, window int
+		valid         bool
+	}{
+		{100, 60, true},
+		{1, 1, true},
+		{0, 60, false},
+		{-1, 60, false},
+		{100, 0, false},
+		{100, -60, false},
+		{0, 0, false},
+	} {
+		err := Settings{Limit: tc.limit, WindowSeconds: tc.window}.Validate()
+		if (err == nil) != tc.valid {
+			t.Errorf("Validate(limit=%d, window=%d) = %v, want valid=%v", tc.limit, tc.window, err, tc.valid)
+		}
+	}
+}
+
+func TestLiveSettingsStoreKeepsValidSettings(t *testing.T) {
+	live := NewLiveSettings(Settings{Limit: 100, WindowSeconds: 60, Mode: "fixed"})
+
+	live.Store(Settings{Limit: 0, WindowSeconds: 60, Mode: "fixed"})
+	live.Store(Settings{Limit: 100, WindowSeconds: -1, Mode: "fixed"})
+	if s := live.Load(); s.Limit != 100 || s.WindowSeconds != 60 {
+		t.Fatalf("invalid settings were stored: %+v", s)
+	}
+
+	live.Store(Settings{Limit: 5, WindowSeconds: 10, Mode: "fixed"})
+	if s := live.Load(); s.Limit != 5 || s.WindowSeconds != 10 {
+		t.Fatalf("valid settings were not stored: %+v", s)
+	}
+}
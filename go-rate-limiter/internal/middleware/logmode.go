@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Log modes, for Options.LogMode and RequestLogger.
+const (
+	LogAll        = "all"         // every request (the default)
+	LogBlocksOnly = "blocks_only" // rejections only: 429 (or RejectStatus) and 403
+	LogNone       = "none"        // nothing per request
+)
+
+// validLogMode reports whether mode is a log mode; empty means LogAll.
+func validLogMode(mode string) bool {
+	switch mode {
+	case "", LogAll, LogBlocksOnly, LogNone:
+		return true
+	}
+	return false
+}
+
+// RequestLogger returns Gin's access logger filtered by logMode, to use
+// in place of gin.Logger: LogBlocksOnly writes only rejected (403 and
+// rejectStatus, 429 when zero) and failed (5xx) requests, LogNone returns
+// nil — install no logger. With anonymous the client IP column is left
+// out, as in AnonymousLogger.
+func RequestLogger(logMode string, rejectStatus int, anonymous bool) gin.HandlerFunc {
+	if rejectStatus == 0 {
+		rejectStatus = http.StatusTooManyRequests
+	}
+
+	var conf gin.LoggerConfig
+	if anonymous {
+		conf.Formatter = anonymousFormatter
+	}
+
+	switch logMode {
+	case LogNone:
+		return nil
+	case LogBlocksOnly:
+		conf.Skip = func(c *gin.Context) bool {
+			status := c.Writer.Status()
+			return status != http.StatusForbidden && status != rejectStatus &&
+				status < http.StatusInternalServerError
+		}
+	}
+	return gin.LoggerWithConfig(conf)
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultMethod is the Options.Methods key whose limit applies to HTTP
+// methods without an entry of their own.
+const DefaultMethod = "*"
+
+// methodLimit returns the limit and window for method from methods: its
+// own entry, else the DefaultMethod entry, else ok is false and the base
+// limit applies.
+func methodLimit(methods map[string]Tier, method string) (limit, windowSeconds int, ok bool) {
+	t, ok := methods[method]
+	if !ok {
+		t, ok = methods[DefaultMethod]
+	}
+	return t.Limit, t.WindowSeconds, ok
+}
+
+// ParseMethodLimits parses a METHOD_LIMITS value such as
+// "GET=1000/60,POST=50/60,*=100/60" — method=limit/window_seconds,
+// comma-separated — into Options.Methods. Method names are upper-cased.
+func ParseMethodLimits(spec string) (map[string]Tier, error) {
+	methods := make(map[string]Tier)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		method, quota, ok := strings.Cut(entry, "=")
+		limit, window, ok2 := strings.Cut(quota, "/")
+		l, err1 := strconv.Atoi(strings.TrimSpace(limit))
+		w, err2 := strconv.Atoi(strings.TrimSpace(window))
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if !ok || !ok2 || err1 != nil || err2 != nil || l < 1 || w < 1 || method == "" {
+			return nil, fmt.Errorf("invalid method limit %q: want METHOD=limit/window_seconds", entry)
+		}
+		methods[method] = Tier{Limit: l, WindowSeconds: w}
+	}
+	return methods, nil
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MultiplierKey is the Redis key RedisLimitMultiplier reads by default: a
+// positive factor every limit is scaled by. A missing key means 1.
+//
+//	SET goshield:limit_multiplier 1.5   # upstream idle: 50% more headroom
+//	SET goshield:limit_multiplier 0.5   # upstream struggling: halve limits
+//	DEL goshield:limit_multiplier       # back to the configured limits
+const MultiplierKey = "goshield:limit_multiplier"
+
+// MultiplierFunc returns the factor the effective limit is scaled by for
+// the current request — e.g. set from upstream latency or load by an
+// external controller. See Options.LimitMultiplier.
+type MultiplierFunc func(ctx context.Context) float64
+
+// multiplierCache holds the last multiplier read from Redis.
+type multiplierCache struct {
+	rdb        redis.Cmdable
+	key        string
+	ttl        time.Duration
+	snapshot   atomic.Pointer[multiplierSnapshot]
+	refreshing atomic.Bool
+}
+
+// multiplierSnapshot is one reading of the key and when it was taken.
+type multiplierSnapshot struct {
+	factor   float64
+	loadedAt time.Time
+}
+
+// RedisLimitMultiplier returns a MultiplierFunc backed by the Redis key at
+// key, so a controller watching backend health can relax or tighten every
+// limit with a single SET. The value is cached in-process and re-read at
+// most once per ttl; changes take effect within ttl.
+//
+// Values that are not positive finite numbers are ignored with a warning
+// and the previous factor is kept. If a refresh fails the previous value
+// stays in use; until the first read succeeds the factor is 1.
+func RedisLimitMultiplier(rdb redis.Cmdable, key string, ttl time.Duration) MultiplierFunc {
+	cache := &multiplierCache{rdb: rdb, key: key, ttl: ttl}
+
+	return func(ctx context.Context) float64 {
+		snap := cache.snapshot.Load()
+		if snap == nil || time.Since(snap.loadedAt) > cache.ttl {
+			snap = cache.refresh(ctx, snap)
+		}
+		if snap == nil {
+			return 1
+		}
+		return snap.factor
+	}
+}
+
+// refresh re-reads the key and returns the newest snapshot. Only one
+// caller refreshes at a time; the others keep using current.
+func (m *multiplierCache) refresh(ctx context.Context, current *multiplierSnapshot) *multiplierSnapshot {
+	if !m.refreshing.CompareAndSwap(false, true) {
+		return current
+	}
+	defer m.refreshing.Store(false)
+
+	raw, err := m.rdb.Get(ctx, m.key).Result()
+	previous := 1.0
+	if current != nil {
+		previous = current.factor
+	}
+
+	factor := 1.0
+	switch {
+	case errors.Is(err, redis.Nil):
+		// unset: the configured limits
+	case err != nil:
+		slog.Warn("could not refresh the limit multiplier", "key", m.key, "err", err)
+		return current
+	default:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil || f <= 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+			slog.Warn("ignoring invalid limit multiplier", "key", m.key, "value", raw)
+			f = previous
+		}
+		factor = f
+	}
+
+	if factor != previous {
+		slog.Info("limit multiplier changed", "multiplier", factor)
+	}
+	snap := &multiplierSnapshot{factor: factor, loadedAt: time.Now()}
+	m.snapshot.Store(snap)
+	return snap
+}
+
+// scaleLimit applies factor to limit, rounding down but never below one
+// request, so a tiny multiplier throttles instead of blocking everyone.
+func scaleLimit(limit int, factor float64) int {
+	if factor == 1 {
+		return limit
+	}
+	return max(1, int(float64(limit)*factor))
+}
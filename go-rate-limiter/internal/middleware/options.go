@@ -0,0 +1,360 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/audit"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc extracts the identifier a request is counted against, e.g. the
+// client IP, an API key header or a JWT subject. Key on c.ClientIP(), not
+// on X-Forwarded-For directly: it only honours forwarding headers from
+// trusted proxies (see config.SetTrustedProxies). A KeyFunc may reject a
+// request by aborting c (see JWTKeyFunc); it is then not counted.
+type KeyFunc func(c *gin.Context) string
+
+// CostFunc returns how many units of the limit a request consumes, so
+// expensive endpoints can be charged more than cheap ones. Values below 1
+// are treated as 1.
+type CostFunc func(c *gin.Context) int
+
+// RejectHandler writes the response for a request over its limit. The
+// X-RateLimit-* and Retry-After headers are already set, and the chain is
+// aborted after it returns, so it only has to choose the status and body.
+type RejectHandler func(c *gin.Context, result Result)
+
+// Scopes for Options.Scope.
+const (
+	ScopePerIP  = "per_ip" // each identifier (client IP by default) has its own budget
+	ScopeGlobal = "global" // one budget shared by every client
+)
+
+// globalKey is the identifier every request counts against in ScopeGlobal.
+const globalKey = "global"
+
+// TenantFunc returns the tenant a request belongs to, e.g. from an
+// X-Tenant-ID header (see HeaderTenant). Empty means DefaultTenant.
+type TenantFunc func(c *gin.Context) string
+
+// TierFunc resolves the limit and window to apply to a request, e.g. from
+// the plan attached to the caller's API key. See APIKeyTiers.
+type TierFunc func(c *gin.Context) (limit, windowSeconds int)
+
+// Options configures RateLimiterWithOptions.
+type Options struct {
+	Limit         int      // max requests allowed per window
+	WindowSeconds int      // window duration in seconds
+	Mode          string   // "fixed", "sliding" (default), "sliding_counter", "token_bucket", "leaky_bucket", "calendar"
+	KeyFunc       KeyFunc  // request identifier; defaults to c.ClientIP()
+	CostFunc      CostFunc // units charged per request; defaults to 1
+
+	// IdentityHeader, when set, keys requests on this header's value
+	// (e.g. "X-User-ID" from an authenticating proxy) instead of KeyFunc,
+	// for per-user limits. It is only honoured on requests whose TCP peer
+	// is in IdentityProxies; everything else, and requests without a
+	// usable value, fall back to KeyFunc.
+	IdentityHeader string
+
+	// TenantFunc, when set, namespaces every identifier by tenant, so
+	// tenants sharing one GoShield and one Redis never share counters:
+	// the same IP is counted separately for each tenant, and a global
+	// scope becomes one budget per tenant.
+	TenantFunc TenantFunc
+
+	// HashKeys replaces every identifier with its salted SHA-256 (HMAC
+	// keyed by HashSalt) before it is used as a store key, and logs the
+	// client IP hashed the same way, so no IP or user ID is stored in
+	// plaintext. Admin lookups and overrides then take the hashed form.
+	HashKeys bool
+
+	// HashSalt is the secret HashKeys hashes with. Every instance must use
+	// the same one; changing it starts every client on a fresh budget.
+	HashSalt string
+
+	// IdentityProxies lists the IPs / CIDRs allowed to send
+	// IdentityHeader, usually the same as TRUSTED_PROXIES. Required when
+	// IdentityHeader is set.
+	IdentityProxies []string
+
+	// Burst lets fixed-window clients overshoot Limit by this many units
+	// per window, so a client that was quiet most of the window is not
+	// cut off at exactly Limit. Headers still report Limit; the overshoot
+	// is reported separately (Result.Overshoot). Other modes ignore it —
+	// token_bucket already allows bursts of up to Limit after idling.
+	Burst int
+
+	// CalendarPeriod is the period of the "calendar" mode: every window
+	// ends at the next UTC "hour", "day" (default) or "month" boundary,
+	// the same instant for every client, instead of WindowSeconds after
+	// its first request. WindowSeconds is ignored in that mode.
+	CalendarPeriod string
+
+	// Rules adds limits enforced alongside Limit / WindowSeconds, for
+	// layered quotas such as 10/second and 5000/day. Each rule counts in
+	// its own bucket; a request over any of them is rejected, and the
+	// headers and Retry-After describe the most restrictive one. The
+	// store must implement ratelimiter.RefundStore.
+	Rules []Tier
+
+	// Live, when set, supplies Limit, WindowSeconds and Mode instead of
+	// the fields above, re-read on every request so they can be changed
+	// at runtime (see LiveSettings).
+	Live *LiveSettings
+
+	// Scope is ScopePerIP (the default) or ScopeGlobal. A global limit
+	// counts all clients against one key (e.g. 1000 req/min in total) to
+	// protect a fragile upstream; it ignores KeyFunc.
+	Scope string
+
+	// RejectHandler customises the response to blocked requests, e.g. to
+	// match an existing API error contract. Defaults to
+	// 429 {"error":"Too many requests","limit":…,"window_seconds":…}.
+	RejectHandler RejectHandler
+
+	// RejectStatus is the status of the default rejection, and of the
+	// distinct-client limit's, for clients that expect e.g. 503 rather
+	// than 429 when throttled. Must be a 4xx or 5xx; defaults to 429.
+	RejectStatus int
+
+	// RetryAfterDate writes Retry-After as an HTTP-date ("Fri, 16 Oct 2026
+	// 08:45:00 GMT") instead of delta-seconds, for clients that only
+	// understand that form. Both carry the same wait, rounded up to a
+	// whole second.
+	RetryAfterDate bool
+
+	// Methods, when set, gives HTTP methods their own limit and window,
+	// e.g. {"GET": {1000, 60}, "POST": {50, 60}}; the DefaultMethod ("*")
+	// entry covers the rest, and methods without either keep Limit and
+	// WindowSeconds. Each method is counted in its own bucket (the
+	// identifier plus ":"+method), so reads never use up the write budget.
+	Methods map[string]Tier
+
+	// TierFunc, when set, overrides Limit and WindowSeconds per request
+	// (e.g. free / pro / enterprise plans). It runs before every check.
+	TierFunc TierFunc
+
+	// Countries, when set, gives clients from the listed countries their
+	// own limit and window, e.g. {"CN": {10, 60}} for stricter limits on
+	// traffic from one country. GeoFunc resolves the country; clients
+	// whose country is unknown or unlisted keep the limit resolved so
+	// far. It applies after TierFunc, so it wins over a plan's limit.
+	Countries map[string]Tier
+
+	// GeoFunc resolves a request's country for Countries, e.g.
+	// MaxMindGeo. Required when Countries is set.
+	GeoFunc GeoFunc
+
+	// BlockCache, when positive, remembers rejected clients in-process for
+	// up to this long (and never past their Retry-After), rejecting their
+	// further requests without a store call — during an attack from a few
+	// hot IPs, most of Redis's load is requests that are certain to fail.
+	// About a second is plenty. An admin reset or refund is noticed once
+	// the entry expires. Cached rejections are still logged and counted
+	// as breaches, which costs a store call each when breach tracking is
+	// on. Only window modes are cached.
+	BlockCache time.Duration
+
+	// QueueTimeout, when positive, holds requests over their limit for up
+	// to this long, re-checking when a slot should free up, and only
+	// rejects them if none does — see queue.go. Cached rejections whose
+	// Retry-After fits in the timeout are queued too. Ignored in dry-run.
+	QueueTimeout time.Duration
+
+	// Overrides, when set, replaces the limit for individual identifiers
+	// (e.g. customers with a negotiated quota). It is consulted after
+	// TierFunc and Countries; see RedisOverrides.
+	Overrides OverrideFunc
+
+	// LimitMultiplier, when set, scales the effective limit — configured,
+	// per-method, tier, country or override — by the factor it returns, e.g. from
+	// RedisLimitMultiplier, so an external signal such as upstream health
+	// can relax or tighten every client at once. The result is rounded
+	// down, never below 1. Rules are not scaled.
+	LimitMultiplier MultiplierFunc
+
+	// Store holds the rate-limit state. Defaults to a RedisStore on
+	// config.RDB; use ratelimiter.NewMemoryStore() to run without Redis.
+	Store ratelimiter.Store
+
+	// FailOpen lets requests through unlimited when the rate-limit check
+	// fails (e.g. Redis is down). When false (the default) such requests
+	// are rejected with 500.
+	FailOpen bool
+
+	// Timeout bounds each rate-limit check (default 200ms). A check that
+	// runs out of time fails like any other Redis error, so FailOpen
+	// decides whether the request proceeds.
+	Timeout time.Duration
+
+	// ChargeOnSuccessOnly refunds requests whose response is a 5xx, so
+	// clients are only charged for requests the upstream actually served.
+	// Requests are still admitted against the full charge and refunded
+	// after the handler returns; see ratelimiter.RefundStore for the
+	// races that opens. The store must implement ratelimiter.RefundStore.
+	ChargeOnSuccessOnly bool
+
+	// RefundOnCancel refunds requests whose client disconnected before
+	// the response was complete (the request context was canceled), e.g.
+	// long-polling clients that routinely give up. Like
+	// ChargeOnSuccessOnly it needs a ratelimiter.RefundStore.
+	RefundOnCancel bool
+
+	// LogMode controls the per-request decision log: LogAll (default)
+	// logs allowed requests at info and rejections at warn, LogBlocksOnly
+	// only the rejections, LogNone neither. Pair it with RequestLogger to
+	// quiet Gin's access log the same way.
+	LogMode string
+
+	// DryRun computes and logs every decision but never rejects: requests
+	// over the limit are logged as "would_block", marked with an
+	// X-RateLimit-DryRun: exceeded header and passed on. Use it to tune
+	// new limits against production traffic before enforcing them.
+	DryRun bool
+
+	// SuspiciousThreshold, when positive, counts every rejection per
+	// identifier and logs a "suspicious_client" warning (and increments
+	// metrics.SuspiciousClients) once a client has been rejected this many
+	// times within SuspiciousWindow. The store must implement
+	// ratelimiter.BreachStore.
+	SuspiciousThreshold int
+
+	// SuspiciousWindow is the breach-counting window in seconds (default 60).
+	SuspiciousWindow int
+
+	// BanThreshold, when positive, bans an identifier once it has been
+	// rejected this many times within SuspiciousWindow: for BanDuration
+	// its requests get 403 before the limiter runs. The store must
+	// implement ratelimiter.BreachStore and ratelimiter.BanStore. In
+	// DryRun mode bans are logged but never applied.
+	BanThreshold int
+
+	// BanDuration is how long a ban lasts (default 10 minutes).
+	BanDuration time.Duration
+
+	// UniqueClients, when positive, caps the distinct identifiers that
+	// may reach each route within UniqueClientsWindow — a guard against
+	// scraping spread over many IPs, each under its own limit. Newcomers
+	// beyond the cap get 429; clients already counted are unaffected.
+	// The store must implement ratelimiter.UniqueClientStore (Redis counts
+	// with a HyperLogLog, so the cap is approximate to ~1%).
+	UniqueClients int
+
+	// UniqueClientsWindow is the distinct-client window in seconds
+	// (default 3600).
+	UniqueClientsWindow int
+
+	// SkipPaths lists paths that are never rate limited, e.g. webhooks
+	// that must always get through. Each entry matches that exact path and
+	// everything below it ("/webhooks" covers "/webhooks/stripe"). Matching
+	// requests skip the limiter before any Redis work, though the
+	// BlockList still applies. Defaults to DefaultSkipPaths when nil; pass
+	// an empty slice to limit every path.
+	SkipPaths []string
+
+	// Audit, when set, receives an event for every blocked request —
+	// rate limited, banned or on the BlockList — e.g. audit.NewWebhook
+	// to feed a SIEM. Dry-run decisions are not blocks and are not sent.
+	Audit audit.Sink
+
+	// AllowList holds IPs and CIDR ranges that bypass rate limiting
+	// entirely — matching requests never touch Redis.
+	AllowList []string
+
+	// BlockList holds IPs and CIDR ranges that are rejected with 403
+	// before any Redis work or rate-limit accounting. It takes precedence
+	// over AllowList.
+	BlockList []string
+}
+
+// RateLimiterWithOptions returns a Gin middleware that enforces the rate
+// limit described by opts. The identifier returned by opts.KeyFunc is
+// passed straight to the ratelimiter package, so callers can limit per
+// user or per API key instead of per IP:
+//
+//	middleware.RateLimiterWithOptions(middleware.Options{
+//		Limit:         100,
+//		WindowSeconds: 60,
+//		KeyFunc: func(c *gin.Context) string {
+//			return c.GetHeader("X-API-Key")
+//		},
+//	})
+//
+// It panics if opts fails Validate.
+func RateLimiterWithOptions(opts Options) gin.HandlerFunc {
+	mustValidate(opts)
+	if opts.HashKeys && opts.HashSalt == "" {
+		slog.Warn("hashing identifiers without a salt: hashed IPs can be reversed by brute force")
+	}
+	opts = withKeyFunc(opts)
+
+	if opts.Live != nil {
+		s := opts.Live.Load()
+		opts.Limit, opts.WindowSeconds, opts.Mode = s.Limit, s.WindowSeconds, s.Mode
+	}
+	slog.Info("rate limiter configured",
+		"mode", modeOrDefault(opts.Mode), "scope", opts.Scope, "limit", opts.Limit, "window_seconds", opts.WindowSeconds, "dry_run", opts.DryRun)
+
+	limiter := newLimiter(opts)
+	if len(opts.AllowList) == 0 && len(opts.BlockList) == 0 {
+		return limiter
+	}
+
+	allowed, _ := parseIPList(opts.AllowList) // checked by Validate
+	blocked, _ := parseIPList(opts.BlockList)
+	slog.Info("access lists configured", "allowed", len(allowed), "blocked", len(blocked))
+
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+
+		if containsIP(blocked, ip) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			c.Abort()
+			auditBlock(c, opts.Audit, audit.ReasonBlockList, ip, "", 0, 0)
+			return
+		}
+
+		if containsIP(allowed, ip) {
+			c.Next()
+			return
+		}
+
+		limiter(c)
+	}
+}
+
+// withKeyFunc returns opts with KeyFunc composed from the identity,
+// scope, hashing and tenant options, so every handler sharing opts
+// identifies a request the same way. opts must have passed Validate.
+func withKeyFunc(opts Options) Options {
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = clientIP
+	}
+	if opts.IdentityHeader != "" {
+		opts.KeyFunc = identityKey(opts.IdentityHeader, opts.IdentityProxies, opts.KeyFunc)
+	}
+
+	if opts.Scope == ScopeGlobal {
+		opts.KeyFunc = func(*gin.Context) string { return globalKey }
+	} else {
+		opts.Scope = ScopePerIP // Validate rejects any other scope
+	}
+
+	if opts.HashKeys && opts.Scope == ScopePerIP {
+		opts.KeyFunc = SaltedHashKey(opts.KeyFunc, opts.HashSalt)
+	}
+
+	if opts.TenantFunc != nil {
+		opts.KeyFunc = tenantKey(opts.TenantFunc, opts.KeyFunc)
+	}
+
+	return opts
+}
+
+// clientIP keys requests on the caller's IP address.
+func clientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// OverridesKey is the Redis hash RedisOverrides reads by default: field =
+// identifier (as returned by the KeyFunc), value = that client's limit.
+//
+//	HSET goshield:overrides 203.0.113.7 5000
+const OverridesKey = "goshield:overrides"
+
+// OverrideFunc returns the negotiated limit for identifier, replacing the
+// configured (or tier) limit for that client, and false when there is none.
+type OverrideFunc func(ctx context.Context, identifier string) (limit int, ok bool)
+
+// overrideCache holds the last snapshot of the overrides hash.
+type overrideCache struct {
+	rdb        redis.Cmdable
+	key        string
+	ttl        time.Duration
+	snapshot   atomic.Pointer[overrideSnapshot]
+	refreshing atomic.Bool
+}
+
+// overrideSnapshot is one copy of the hash and when it was read.
+type overrideSnapshot struct {
+	limits   map[string]int
+	loadedAt time.Time
+}
+
+// RedisOverrides returns an OverrideFunc backed by the Redis hash at key,
+// so ops can raise one customer's quota with a single HSET instead of a
+// redeploy. The whole hash is cached in-process and re-read at most once
+// per ttl — overrides are few, so this costs one HGETALL per ttl rather
+// than a round-trip per request. Changes take effect within ttl.
+//
+// If a refresh fails the previous snapshot stays in use; until the first
+// read succeeds every client gets the default limit.
+func RedisOverrides(rdb redis.Cmdable, key string, ttl time.Duration) OverrideFunc {
+	cache := &overrideCache{rdb: rdb, key: key, ttl: ttl}
+
+	return func(ctx context.Context, identifier string) (int, bool) {
+		snap := cache.snapshot.Load()
+		if snap == nil || time.Since(snap.loadedAt) > cache.ttl {
+			snap = cache.refresh(ctx, snap)
+		}
+		if snap == nil {
+			return 0, false
+		}
+		limit, ok := snap.limits[identifier]
+		return limit, ok
+	}
+}
+
+// refresh re-reads the hash and returns the newest snapshot. Only one
+// caller refreshes at a time; the others keep using current.
+func (o *overrideCache) refresh(ctx context.Context, current *overrideSnapshot) *overrideSnapshot {
+	if !o.refreshing.CompareAndSwap(false, true) {
+		return current
+	}
+	defer o.refreshing.Store(false)
+
+	fields, err := o.rdb.HGetAll(ctx, o.key).Result()
+	if err != nil {
+		slog.Warn("could not refresh rate-limit overrides", "key", o.key, "err", err)
+		return current
+	}
+
+	limits := make(map[string]int, len(fields))
+	for id, v := range fields {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			slog.Warn("ignoring invalid rate-limit override", "identifier", id, "value", v)
+			continue
+		}
+		limits[id] = limit
+	}
+
+	snap := &overrideSnapshot{limits: limits, loadedAt: time.Now()}
+	o.snapshot.Store(snap)
+	return snap
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hashedIPKey is the Gin context key holding the pseudonymised client IP
+// that log lines use instead of the real one when Options.HashKeys is set.
+const hashedIPKey = "goshield_hashed_ip"
+
+// hashIdentifier returns the hex HMAC-SHA256 of id under salt. Keying the
+// hash with a secret salt is what makes it one-way in practice: an
+// unsalted hash of an IPv4 address can be reversed by hashing all 2³².
+func hashIdentifier(salt []byte, id string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SaltedHashKey wraps keyFunc so every identifier is replaced by its
+// salted SHA-256 (HMAC) before it reaches the store, e.g.
+// rate:{3f7a…} instead of rate:{203.0.113.7}. The same salt always gives
+// the same key, so every instance must share it.
+func SaltedHashKey(keyFunc KeyFunc, salt string) KeyFunc {
+	key := []byte(salt)
+	return func(c *gin.Context) string {
+		return hashIdentifier(key, keyFunc(c))
+	}
+}
+
+// logIP returns the client IP to put in log lines: the real one, or its
+// salted hash when Options.HashKeys is set.
+func logIP(c *gin.Context) string {
+	if ip := c.GetString(hashedIPKey); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
+
+// AnonymousLogger is gin.Logger without the client IP column, for
+// deployments that must not write IPs to their logs (see Options.HashKeys).
+func AnonymousLogger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(anonymousFormatter)
+}
+
+// anonymousFormatter is gin's default log line minus the client IP.
+func anonymousFormatter(p gin.LogFormatterParams) string {
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %-7s %#v\n%s",
+		p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		p.StatusCode,
+		p.Latency.Truncate(time.Microsecond),
+		p.Method,
+		p.Path,
+		p.ErrorMessage,
+	)
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// ── Request queueing ─────────────────────────────────────────────────
+//
+// With Options.QueueTimeout set, a request over its limit is held instead
+// of rejected: it sleeps until the check says a slot frees up (its
+// Retry-After) and checks again, at most maxQueueRetries times and never
+// past QueueTimeout. A request whose slot cannot free up in time is
+// rejected at once rather than after a pointless wait. Batch clients
+// sending bursts get smoothed out without retry logic of their own.
+//
+// Every held request keeps its goroutine and connection open, so keep the
+// timeout short (a few seconds) and pair it with MAX_CONCURRENCY on a
+// gateway.
+
+// maxQueueRetries bounds the re-checks one queued request makes; other
+// queued clients may take the slot it was waiting for.
+const maxQueueRetries = 5
+
+// queuePoll is how long a queued request waits when the check reports no
+// Retry-After.
+const queuePoll = 50 * time.Millisecond
+
+// queueForSlot holds a rejected request, re-running check whenever a
+// slot should have freed up, until it is admitted, timeout would be
+// exceeded, retries run out or the client goes away. It returns the last
+// result.
+func queueForSlot(c *gin.Context, timeout time.Duration, result *Result, check func() (*Result, error)) (*Result, error) {
+	deadline := time.Now().Add(timeout)
+
+	for range maxQueueRetries {
+		wait := result.RetryAfter
+		if wait <= 0 {
+			wait = queuePoll
+		}
+		if time.Until(deadline) < wait {
+			return result, nil // no slot frees up in time
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-c.Request.Context().Done():
+			timer.Stop()
+			return result, nil
+		case <-timer.C:
+		}
+
+		next, err := check()
+		if err != nil {
+			return nil, err
+		}
+		result = next
+		if result.Allowed {
+			metrics.QueuedAdmits.Add(1)
+			return result, nil
+		}
+	}
+	return result, nil
+}
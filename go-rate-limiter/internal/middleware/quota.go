@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaHandler returns a handler reporting the caller's usage under opts —
+// the same identifier, limit and window the limiter would use — without
+// counting a request. Mount it outside the limiter (e.g. GET /me/quota),
+// or asking costs a request. With Options.Methods set, ?method= picks the
+// method to report (GET by default).
+//
+// Only the fixed and sliding windows can be peeked; other modes answer
+// 501. It panics if opts fails Validate or its store does not implement
+// ratelimiter.PeekStore.
+func QuotaHandler(opts Options) gin.HandlerFunc {
+	mustValidate(opts)
+	opts = withKeyFunc(opts)
+	if opts.Store == nil {
+		opts.Store = ratelimiter.NewRedisStore(config.RDB)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultCheckTimeout
+	}
+
+	store, ok := opts.Store.(ratelimiter.PeekStore)
+	if !ok {
+		panic("middleware: the quota endpoint needs a store that can peek at usage")
+	}
+	burst := max(0, opts.Burst)
+
+	return func(c *gin.Context) {
+		id := opts.KeyFunc(c)
+		if c.IsAborted() {
+			return
+		}
+
+		mode, limit, windowSeconds := opts.Mode, opts.Limit, opts.WindowSeconds
+		if opts.Live != nil {
+			s := opts.Live.Load()
+			mode, limit, windowSeconds = s.Mode, s.Limit, s.WindowSeconds
+		}
+		mode = modeOrDefault(mode)
+
+		key := id
+		if opts.Methods != nil {
+			method := strings.ToUpper(c.DefaultQuery("method", http.MethodGet))
+			if l, w, ok := methodLimit(opts.Methods, method); ok {
+				limit, windowSeconds = l, w
+			}
+			key = id + ":" + method
+		}
+
+		limit, windowSeconds = clientLimit(c, opts, id, limit, windowSeconds)
+
+		ctx, cancel := context.WithTimeout(config.Ctx, opts.Timeout)
+		defer cancel()
+
+		var (
+			result *Result
+			err    error
+		)
+		switch mode {
+		case "fixed":
+			var r *ratelimiter.FixedWindowResult
+			if r, err = store.PeekFixedWindow(ctx, key, limit+burst, windowSeconds); err == nil {
+				result = &Result{Allowed: r.Allowed, Count: r.Count, Limit: limit, WindowSec: r.WindowSec, Reset: r.Reset, RetryAfter: r.RetryAfter}
+			}
+		case "sliding":
+			var r *ratelimiter.SlidingWindowResult
+			if r, err = store.PeekSlidingWindow(ctx, key, limit, windowSeconds); err == nil {
+				result = &Result{Allowed: r.Allowed, Count: r.Count, Limit: r.Limit, WindowSec: r.WindowSec, Reset: r.Reset, RetryAfter: r.RetryAfter}
+			}
+		default:
+			c.JSON(http.StatusNotImplemented, gin.H{"error": "quota is not available in " + mode + " mode"})
+			return
+		}
+		if err != nil {
+			slog.Error("quota peek failed", "mode", mode, "ip", logIP(c), "err", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "quota unavailable"})
+			return
+		}
+
+		setRateLimitHeaders(c, result)
+		c.JSON(http.StatusOK, gin.H{
+			"mode":           mode,
+			"limit":          result.Limit,
+			"used":           result.Count,
+			"remaining":      result.Remaining(),
+			"window_seconds": result.WindowSec,
+			"reset":          result.Reset.UTC().Format(time.RFC3339),
+		})
+	}
+}
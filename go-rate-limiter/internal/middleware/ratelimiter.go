@@ -1,11 +1,19 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/audit"
 	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/metrics"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/pathutil"
 	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/tracing"
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,7 +24,7 @@ import (
 // This middleware intercepts every request and decides whether to allow
 // or block it based on per-IP request counts stored in Redis.
 //
-// Two algorithms are available, both sharing the same guarantees:
+// Five algorithms are available, all sharing the same guarantees:
 //
 //   ┌──────────────────────────────────────────────────────────────────┐
 //   │  O(1) TIME COMPLEXITY                                           │
@@ -24,6 +32,9 @@ import (
 //   │  Fixed window:   INCR + EXPIRE = two O(1) Redis commands.       │
 //   │  Sliding window: ZSET ops are O(log N), but N ≤ limit, so the  │
 //   │                  cost is bounded and effectively constant.       │
+//   │  Sliding counter: two counters in one hash — O(1) time & memory. │
+//   │  Token bucket:   HMGET + HSET + EXPIRE on one small hash — O(1). │
+//   │  Leaky bucket:   same hash layout as token bucket — O(1).        │
 //   │                                                                  │
 //   │  Whether 1 or 100,000 users send requests, each individual      │
 //   │  request performs the same small number of operations.           │
@@ -33,7 +44,7 @@ import (
 //   ┌──────────────────────────────────────────────────────────────────┐
 //   │  ZERO RACE CONDITIONS                                           │
 //   │                                                                  │
-//   │  Both algorithms execute all Redis operations inside a single   │
+//   │  All algorithms execute all Redis operations inside a single    │
 //   │  atomic Lua script. Redis is single-threaded; it runs each      │
 //   │  script to completion before processing the next command.        │
 //   │                                                                  │
@@ -67,88 +78,573 @@ import (
 //   - limit:         max requests allowed per window (e.g. 100)
 //   - windowSeconds: window duration in seconds (e.g. 60)
 //   - mode:          "fixed" for O(1) fixed-window counter,
+//                    "token_bucket" for burst-friendly token bucket,
+//                    "leaky_bucket" for constant-rate traffic shaping,
+//                    "sliding_counter" for approximate O(1)-memory sliding window,
+//                    "calendar" for a fixed window ending at midnight UTC,
 //                    "sliding" (default) for sliding-window ZSET.
 //
-// Both modes guarantee O(1) effective time complexity and zero race
+// All modes guarantee O(1) effective time complexity and zero race
 // conditions via atomic Redis Lua scripts.
 func RateLimiter(limit int, windowSeconds int, mode string) gin.HandlerFunc {
+	return RateLimiterWithOptions(Options{
+		Limit:         limit,
+		WindowSeconds: windowSeconds,
+		Mode:          mode,
+	})
+}
+
+// modeOrDefault returns mode, or "sliding" when it is empty.
+func modeOrDefault(mode string) string {
 	if mode == "" {
-		mode = "sliding"
+		return "sliding"
 	}
-	log.Printf("⚙️  Rate-limit mode: %s  |  limit: %d  |  window: %ds", mode, limit, windowSeconds)
+	return mode
+}
+
+// Result is the outcome of one rate-limit check, normalised across modes.
+type Result struct {
+	Allowed    bool          // whether the request may proceed
+	Count      int64         // units counted (or bucket slots used) so far
+	Limit      int           // configured max requests per window / capacity
+	WindowSec  int           // window duration in seconds
+	Reset      time.Time     // when the window frees up; zero for bucket modes
+	RetryAfter time.Duration // time until a slot frees up; zero when allowed
+	Overshoot  int64         // fixed window: units admitted above Limit from the burst allowance
+}
+
+// Remaining returns how many units are left in the window (or bucket),
+// never below zero.
+func (r *Result) Remaining() int64 {
+	return max(0, int64(r.Limit)-r.Count)
+}
+
+// Gin context keys under which the limiter stores the decision for
+// downstream handlers and middleware.
+const (
+	ResultKey         = "ratelimit_result"    // *Result
+	GoShieldResultKey = "goshield.result"     // *Result, same as ResultKey
+	RemainingKey      = "ratelimit_remaining" // int64, same as X-RateLimit-Remaining
+)
+
+// setResult stores result on c under the context keys above.
+func setResult(c *gin.Context, result *Result) {
+	c.Set(ResultKey, result)
+	c.Set(GoShieldResultKey, result)
+	c.Set(RemainingKey, result.Remaining())
+}
 
-	if mode == "fixed" {
-		return fixedWindowLimiter(limit, windowSeconds)
+// GetResult returns the rate-limit decision stored on c by the limiter,
+// e.g. to show quota usage in a client dashboard.
+func GetResult(c *gin.Context) (*Result, bool) {
+	v, ok := c.Get(ResultKey)
+	if !ok {
+		return nil, false
 	}
-	return slidingWindowLimiter(limit, windowSeconds)
+	r, ok := v.(*Result)
+	return r, ok
 }
 
-// ── Fixed-window limiter ──────────────────────────────────────────────
-//
-// Uses the atomic Lua script in ratelimiter.CheckFixedWindow which
-// performs INCR + conditional EXPIRE in a single uninterruptible call.
-//
-// Time complexity:  O(1) per request — guaranteed.
-// Race conditions:  Zero — guaranteed by atomic Lua execution.
-func fixedWindowLimiter(limit int, windowSeconds int) gin.HandlerFunc {
+// DefaultSkipPaths are the paths Options.SkipPaths exempts when unset:
+// the liveness and readiness probes, which must answer even for a
+// throttled load balancer or orchestrator.
+var DefaultSkipPaths = []string{"/health", "/ready"}
+
+// skipPath reports whether path is exempt from rate limiting: equal to,
+// or below, one of skip.
+func skipPath(path string, skip []string) bool {
+	for _, p := range skip {
+		if pathutil.MatchesPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultCheckTimeout bounds a rate-limit check when Options.Timeout is
+// unset, so a hung Redis connection cannot stall requests indefinitely.
+const defaultCheckTimeout = 200 * time.Millisecond
+
+// checkFunc runs one rate-limit check for identifier against limit
+// requests per windowSeconds, charging cost units.
+type checkFunc func(ctx context.Context, identifier string, limit, windowSeconds, cost int) (*Result, error)
+
+// validateTiers reports a per-method or per-country limit in opts that is
+// not positive, like the configured limit itself.
+func validateTiers(opts Options) error {
+	for method, t := range opts.Methods {
+		if err := (Settings{Limit: t.Limit, WindowSeconds: t.WindowSeconds}).Validate(); err != nil {
+			return fmt.Errorf("method limit %s: %w", method, err)
+		}
+	}
+	for country, t := range opts.Countries {
+		if err := (Settings{Limit: t.Limit, WindowSeconds: t.WindowSeconds}).Validate(); err != nil {
+			return fmt.Errorf("country limit %s: %w", country, err)
+		}
+	}
+	return nil
+}
+
+// clientLimit applies the per-client limit sources to limit and
+// windowSeconds, in order: TierFunc, Countries, Overrides, then
+// LimitMultiplier. They are resolved per request, so a tier or override
+// that is not positive is logged and skipped, keeping the limit resolved
+// so far — a bad plan entry must neither block its clients nor give the
+// store a zero-length window.
+func clientLimit(c *gin.Context, opts Options, id string, limit, windowSeconds int) (int, int) {
+	if opts.TierFunc != nil {
+		l, w := opts.TierFunc(c)
+		if err := (Settings{Limit: l, WindowSeconds: w}).Validate(); err != nil {
+			slog.Error("ignoring invalid tier limit", "ip", logIP(c), "err", err)
+		} else {
+			limit, windowSeconds = l, w
+		}
+	}
+	if opts.Countries != nil && opts.GeoFunc != nil {
+		if l, w, ok := countryLimit(opts.Countries, opts.GeoFunc, c); ok {
+			limit, windowSeconds = l, w
+		}
+	}
+	if opts.Overrides != nil {
+		if override, ok := opts.Overrides(c.Request.Context(), id); ok {
+			if override > 0 {
+				limit = override
+			} else {
+				slog.Error("ignoring invalid rate-limit override", "ip", logIP(c), "limit", override)
+			}
+		}
+	}
+	if opts.LimitMultiplier != nil {
+		limit = scaleLimit(limit, opts.LimitMultiplier(c.Request.Context()))
+	}
+	return limit, windowSeconds
+}
+
+// newLimiter builds the limiter for opts.Mode, counting requests against
+// the identifier returned by opts.KeyFunc in opts.Store (Redis by default).
+// It panics if opts fails Validate.
+func newLimiter(opts Options) gin.HandlerFunc {
+	mustValidate(opts)
+	if opts.Store == nil {
+		opts.Store = ratelimiter.NewRedisStore(config.RDB)
+	}
+
+	if opts.RejectStatus == 0 {
+		opts.RejectStatus = http.StatusTooManyRequests
+	}
+	if opts.RejectHandler == nil {
+		opts.RejectHandler = defaultReject(opts.RejectStatus)
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultCheckTimeout
+	}
+
+	if opts.SkipPaths == nil {
+		opts.SkipPaths = DefaultSkipPaths
+	}
+
+	var salt []byte
+	if opts.HashKeys {
+		salt = []byte(opts.HashSalt)
+	}
+
+	if opts.CalendarPeriod == "" {
+		opts.CalendarPeriod = ratelimiter.CalendarDay
+	}
+
+	trackBreach := newBreachTracker(opts)
+	banned := newBanCheck(opts)
+	uniqueExceeded := newUniqueClientsCheck(opts)
+	refund := newRefunder(opts)
+	blocked := newBlockCache(opts.BlockCache)
+	if opts.DryRun {
+		blocked = nil // nothing is ever rejected
+	}
+
+	// Every mode's check is built up front, so live settings can switch
+	// between them; unknown modes fall back to the sliding window.
+	checks := map[string]checkFunc{
+		"fixed":           fixedWindowCheck(opts.Store, opts.Burst),
+		"sliding":         slidingWindowCheck(opts.Store),
+		"sliding_counter": slidingCounterCheck(opts.Store),
+		"token_bucket":    tokenBucketCheck(opts.Store),
+		"leaky_bucket":    leakyBucketCheck(opts.Store),
+		"calendar":        calendarWindowCheck(opts.Store, opts.CalendarPeriod),
+	}
+	if len(opts.Rules) > 0 {
+		refunds := rulesRefundStore(opts)
+		for mode, check := range checks {
+			checks[mode] = withRules(check, mode, refunds, opts.Rules)
+		}
+	}
+
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
+		if skipPath(c.Request.URL.Path, opts.SkipPaths) {
+			c.Next()
+			return
+		}
+
+		if salt != nil {
+			c.Set(hashedIPKey, hashIdentifier(salt, c.ClientIP()))
+		}
+
+		id := opts.KeyFunc(c)
+		if c.IsAborted() {
+			return // rejected by the KeyFunc, e.g. an invalid JWT
+		}
+		if banned != nil && banned(c, id) {
+			return
+		}
+		if uniqueExceeded != nil && uniqueExceeded(c, id) {
+			return
+		}
+		cost := requestCost(c, opts.CostFunc)
+
+		mode, limit, windowSeconds := opts.Mode, opts.Limit, opts.WindowSeconds
+		if opts.Live != nil {
+			s := opts.Live.Load()
+			mode, limit, windowSeconds = s.Mode, s.Limit, s.WindowSeconds
+		}
+		mode = modeOrDefault(mode)
+
+		check, ok := checks[mode]
+		if !ok {
+			check = checks["sliding"]
+		}
+
+		key := id
+		if opts.Methods != nil {
+			if l, w, ok := methodLimit(opts.Methods, c.Request.Method); ok {
+				limit, windowSeconds = l, w
+			}
+			key = id + ":" + c.Request.Method
+		}
+
+		limit, windowSeconds = clientLimit(c, opts, id, limit, windowSeconds)
+
+		start := time.Now()
+		if blocked != nil {
+			if result, ok := blocked.get(mode + ":" + key); ok && result.RetryAfter > opts.QueueTimeout {
+				// Answered without the store, but still a rejection: logged
+				// and counted as a breach like any other.
+				metrics.BlockCacheHits.Add(1)
+				if opts.LogMode != LogNone {
+					logDecision(c, mode, result, time.Since(start), false)
+				}
+				setResult(c, result)
+				setRateLimitHeaders(c, result)
+				if trackBreach != nil {
+					trackBreach(c, id)
+				}
+				setRetryAfter(c, result.RetryAfter, opts.RetryAfterDate)
+				opts.RejectHandler(c, *result)
+				c.Abort()
+				auditBlock(c, opts.Audit, audit.ReasonRateLimit, id, mode, result.Limit, result.WindowSec)
+				return
+			}
+		}
+
+		run := func() (*Result, error) {
+			base, cancel := context.WithTimeout(config.Ctx, opts.Timeout)
+			defer cancel()
+			ctx, span := tracing.StartCheck(c.Request.Context(), base, mode, key)
+			result, err := check(ctx, key, limit, windowSeconds, cost)
+			tracing.EndCheck(span, result != nil && result.Allowed, err)
+			return result, err
+		}
+
+		result, err := run()
+		if err == nil && !result.Allowed && opts.QueueTimeout > 0 && !opts.DryRun {
+			result, err = queueForSlot(c, opts.QueueTimeout, result, run)
+		}
 
-		result, err := ratelimiter.CheckFixedWindow(
-			config.Ctx, config.RDB, ip, limit, windowSeconds,
-		)
 		if err != nil {
-			log.Printf("❌ Fixed-window error: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Redis error"})
-			c.Abort()
+			slog.Error("rate limit check failed", "mode", mode, "ip", logIP(c), "fail_open", opts.FailOpen, "err", err)
+			checkFailed(c, opts.FailOpen)
 			return
 		}
+		if opts.LogMode != LogNone && (!result.Allowed || opts.LogMode != LogBlocksOnly) {
+			logDecision(c, mode, result, time.Since(start), opts.DryRun)
+		}
+
+		setResult(c, result)
+
+		// Window modes know when they reset; bucket modes have no window.
+		windowed := !result.Reset.IsZero()
+		if windowed {
+			setRateLimitHeaders(c, result)
+		}
 
-		if !result.Allowed {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":          "Too many requests",
-				"limit":          result.Limit,
-				"window_seconds": result.WindowSec,
-			})
+		if !result.Allowed && trackBreach != nil {
+			trackBreach(c, id)
+		}
+
+		if !result.Allowed && opts.DryRun {
+			// Monitor only: report what enforcement would do, then proceed.
+			c.Header("X-RateLimit-DryRun", "exceeded")
+		} else if !result.Allowed {
+			if windowed {
+				setRetryAfter(c, result.RetryAfter, opts.RetryAfterDate)
+				if blocked != nil {
+					blocked.add(mode+":"+key, result)
+				}
+			}
+			opts.RejectHandler(c, *result)
 			c.Abort()
+			auditBlock(c, opts.Audit, audit.ReasonRateLimit, id, mode, result.Limit, result.WindowSec)
 			return
 		}
 
 		c.Next()
+
+		if refund != nil && result.Allowed {
+			refund(c, mode, key, cost, limit)
+		}
+	}
+}
+
+// requestCost returns the units c consumes: costFunc's value, at least 1,
+// or 1 when no CostFunc is configured.
+func requestCost(c *gin.Context, costFunc CostFunc) int {
+	if costFunc == nil {
+		return 1
+	}
+	return max(1, costFunc(c))
+}
+
+// logDecision emits one structured log line per rate-limit decision:
+// allowed requests at info level, rejected ones at warn. In dry-run mode
+// rejections are logged as "would_block", since the request still proceeds.
+func logDecision(c *gin.Context, mode string, result *Result, latency time.Duration, dryRun bool) {
+	level, msg := slog.LevelInfo, "rate limit decision"
+	if !result.Allowed {
+		level = slog.LevelWarn
+		if dryRun {
+			msg = "rate limit would_block"
+		}
+	}
+	slog.Log(c.Request.Context(), level, msg,
+		"request_id", GetRequestID(c),
+		"ip", logIP(c),
+		"mode", mode,
+		"count", result.Count,
+		"limit", result.Limit,
+		"allowed", result.Allowed,
+		"dry_run", dryRun,
+		"overshoot", result.Overshoot,
+		"latency_ms", float64(latency.Microseconds())/1000,
+	)
+}
+
+// defaultReject returns the built-in RejectHandler: status (429 unless
+// Options.RejectStatus says otherwise) with a JSON body.
+func defaultReject(status int) RejectHandler {
+	return func(c *gin.Context, result Result) {
+		c.JSON(status, gin.H{
+			"error":          "Too many requests",
+			"limit":          result.Limit,
+			"window_seconds": result.WindowSec,
+		})
+	}
+}
+
+// checkFailed responds to a rate-limit check that could not be completed
+// (e.g. Redis is unreachable). With failOpen the request proceeds
+// unlimited; otherwise it is rejected with 500.
+func checkFailed(c *gin.Context, failOpen bool) {
+	if failOpen {
+		c.Next()
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Redis error"})
+	c.Abort()
+}
+
+// ── Fixed-window limiter ──────────────────────────────────────────────
+//
+// Uses Store.FixedWindow — by default the atomic Lua script in
+// ratelimiter.CheckFixedWindow, which performs INCRBY + conditional EXPIRE
+// in a single uninterruptible call.
+//
+// With a burst allowance the store enforces limit+burst, while the
+// result keeps reporting limit and how far past it the window has gone.
+//
+// Time complexity:  O(1) per request — guaranteed.
+// Race conditions:  Zero — guaranteed by atomic Lua execution.
+func fixedWindowCheck(store ratelimiter.Store, burst int) checkFunc {
+	burst = max(0, burst)
+	return func(ctx context.Context, id string, limit, windowSeconds, cost int) (*Result, error) {
+		r, err := store.FixedWindow(ctx, id, limit+burst, windowSeconds, cost)
+		if err != nil {
+			return nil, err
+		}
+		result := &Result{
+			Allowed:    r.Allowed,
+			Count:      r.Count,
+			Limit:      limit,
+			WindowSec:  r.WindowSec,
+			Reset:      r.Reset,
+			RetryAfter: r.RetryAfter,
+		}
+		if r.Allowed {
+			result.Overshoot = max(0, r.Count-int64(limit))
+		}
+		return result, nil
 	}
 }
 
 // ── Sliding-window limiter ────────────────────────────────────────────
 //
-// Uses the atomic Lua script in ratelimiter.CheckSlidingWindow which
-// performs ZREMRANGEBYSCORE + ZADD + ZCARD + EXPIRE in a single call.
+// Uses Store.SlidingWindow — by default the atomic Lua script in
+// ratelimiter.CheckSlidingWindow, which performs ZREMRANGEBYSCORE + ZCARD +
+// ZADD (only when the request fits) + EXPIRE in a single call.
 //
 // Time complexity:  Amortised O(1) — ZSET size bounded by limit.
 // Race conditions:  Zero — guaranteed by atomic Lua execution.
-func slidingWindowLimiter(limit int, windowSeconds int) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ip := c.ClientIP()
+func slidingWindowCheck(store ratelimiter.Store) checkFunc {
+	return func(ctx context.Context, id string, limit, windowSeconds, cost int) (*Result, error) {
+		r, err := store.SlidingWindow(ctx, id, limit, windowSeconds, cost)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{
+			Allowed:    r.Allowed,
+			Count:      r.Count,
+			Limit:      r.Limit,
+			WindowSec:  r.WindowSec,
+			Reset:      r.Reset,
+			RetryAfter: r.RetryAfter,
+		}, nil
+	}
+}
 
-		result, err := ratelimiter.CheckSlidingWindow(
-			config.Ctx, config.RDB, ip, limit, windowSeconds,
-		)
+// ── Sliding-window-counter limiter ────────────────────────────────────
+//
+// Uses Store.SlidingCounter — by default the atomic Lua script in
+// ratelimiter.CheckSlidingCounter, which blends the current and previous
+// fixed-window counts instead of storing every request.
+//
+// Time complexity:  O(1) per request, O(1) memory per client.
+// Race conditions:  Zero — guaranteed by atomic Lua execution.
+func slidingCounterCheck(store ratelimiter.Store) checkFunc {
+	return func(ctx context.Context, id string, limit, windowSeconds, cost int) (*Result, error) {
+		r, err := store.SlidingCounter(ctx, id, limit, windowSeconds, cost)
 		if err != nil {
-			log.Printf("❌ Sliding-window error: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Redis error"})
-			c.Abort()
-			return
+			return nil, err
 		}
+		return &Result{
+			Allowed:    r.Allowed,
+			Count:      r.Count,
+			Limit:      r.Limit,
+			WindowSec:  r.WindowSec,
+			Reset:      r.Reset,
+			RetryAfter: r.RetryAfter,
+		}, nil
+	}
+}
 
-		if !result.Allowed {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":          "Too many requests",
-				"limit":          result.Limit,
-				"window_seconds": result.WindowSec,
-			})
-			c.Abort()
-			return
+// ── Calendar-window limiter ───────────────────────────────────────────
+//
+// Uses Store.CalendarWindow — by default the atomic Lua script in
+// ratelimiter.CheckCalendarWindow: a fixed window whose key expires at
+// the next UTC boundary of period rather than windowSeconds after the
+// client's first request.
+//
+// Time complexity:  O(1) per request — guaranteed.
+// Race conditions:  Zero — guaranteed by atomic Lua execution.
+func calendarWindowCheck(store ratelimiter.Store, period string) checkFunc {
+	return func(ctx context.Context, id string, limit, _, cost int) (*Result, error) {
+		r, err := store.CalendarWindow(ctx, id, limit, period, cost)
+		if err != nil {
+			return nil, err
 		}
+		return &Result{
+			Allowed:    r.Allowed,
+			Count:      r.Count,
+			Limit:      r.Limit,
+			WindowSec:  r.WindowSec,
+			Reset:      r.Reset,
+			RetryAfter: r.RetryAfter,
+		}, nil
+	}
+}
 
-		c.Next()
+// setRateLimitHeaders writes the de-facto standard X-RateLimit-* headers
+// (as used by GitHub) so well-behaved clients can back off before they are
+// rejected. Remaining is clamped at zero; Reset is Unix epoch seconds.
+func setRateLimitHeaders(c *gin.Context, result *Result) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining(), 10))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt((result.Reset.UnixMilli()+999)/1000, 10))
+}
+
+// setRetryAfter writes the standard Retry-After header on rejected
+// responses: delta-seconds, or with asDate the equivalent HTTP-date. The
+// wait is rounded up and never below one second, so a client that
+// honours it will not be rejected again immediately.
+func setRetryAfter(c *gin.Context, wait time.Duration, asDate bool) {
+	secs := int64((wait + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	if asDate {
+		at := time.Now().Add(time.Duration(secs) * time.Second)
+		c.Header("Retry-After", at.UTC().Format(http.TimeFormat))
+		return
+	}
+	c.Header("Retry-After", strconv.FormatInt(secs, 10))
+}
+
+// ── Token-bucket limiter ──────────────────────────────────────────────
+//
+// Uses Store.TokenBucket — by default the atomic Lua script in
+// ratelimiter.CheckTokenBucket. The bucket holds up to `limit` tokens and
+// refills at limit/windowSeconds tokens per second, so clients may burst
+// up to `limit` requests after being idle while the long-run average stays
+// at `limit` per window.
+//
+// Time complexity:  O(1) per request — one hash read and write.
+// Race conditions:  Zero — guaranteed by atomic Lua execution.
+func tokenBucketCheck(store ratelimiter.Store) checkFunc {
+	return func(ctx context.Context, id string, limit, windowSeconds, cost int) (*Result, error) {
+		refillPerSec := float64(limit) / float64(windowSeconds)
+
+		r, err := store.TokenBucket(ctx, id, limit, refillPerSec, cost)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{
+			Allowed:   r.Allowed,
+			Count:     int64(r.Capacity) - r.Tokens,
+			Limit:     r.Capacity,
+			WindowSec: windowSeconds,
+		}, nil
+	}
+}
+
+// ── Leaky-bucket limiter ──────────────────────────────────────────────
+//
+// Uses Store.LeakyBucket — by default the atomic Lua script in
+// ratelimiter.CheckLeakyBucket. The virtual queue holds up to `limit`
+// requests and drains at limit/windowSeconds requests per second, so
+// admitted traffic flows out at a steady rate instead of in bursts.
+//
+// Time complexity:  O(1) per request — one hash read and write.
+// Race conditions:  Zero — guaranteed by atomic Lua execution.
+func leakyBucketCheck(store ratelimiter.Store) checkFunc {
+	return func(ctx context.Context, id string, limit, windowSeconds, cost int) (*Result, error) {
+		leakRatePerSec := float64(limit) / float64(windowSeconds)
+
+		r, err := store.LeakyBucket(ctx, id, limit, leakRatePerSec, cost)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{
+			Allowed:   r.Allowed,
+			Count:     r.Level,
+			Limit:     r.Capacity,
+			WindowSec: windowSeconds,
+		}, nil
 	}
 }
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/gin-gonic/gin"
+)
+
+func TestResultInContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := ratelimiter.NewMemoryStore()
+	defer store.Close()
+
+	var got any
+	r := gin.New()
+	r.Use(RateLimiterWithOptions(Options{Limit: 5, WindowSeconds: 60, Store: store}))
+	r.GET("/", func(c *gin.Context) {
+		got, _ = c.Get("goshield.result")
+		c.Status(http.StatusNoContent)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	result, ok := got.(*Result)
+	if !ok {
+		t.Fatalf(`c.Get("goshield.result") = %T, want *Result`, got)
+	}
+	if result.Count != 1 || result.Limit != 5 || result.Remaining() != 4 {
+		t.Fatalf("result count=%d limit=%d remaining=%d, want 1, 5, 4", result.Count, result.Limit, result.Remaining())
+	}
+}
+
+// A tier or override that is not positive must not replace the configured
+// limit: a limit of 0 would block the client, a window of 0 would make the
+// fixed window's key expire at once.
+func TestInvalidClientLimitsFallBack(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for name, opts := range map[string]Options{
+		"tier limit":  {TierFunc: func(*gin.Context) (int, int) { return 0, 60 }},
+		"tier window": {TierFunc: func(*gin.Context) (int, int) { return 50, 0 }},
+		"override":    {Overrides: func(context.Context, string) (int, bool) { return 0, true }},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := ratelimiter.NewMemoryStore()
+			defer store.Close()
+
+			opts.Limit, opts.WindowSeconds, opts.Mode, opts.Store = 3, 60, "fixed", store
+			r := gin.New()
+			r.Use(RateLimiterWithOptions(opts))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+			for i := 1; i <= 4; i++ {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+				want := http.StatusNoContent
+				if i == 4 {
+					want = http.StatusTooManyRequests
+				}
+				if w.Code != want {
+					t.Fatalf("request %d: status %d, want %d", i, w.Code, want)
+				}
+				if got := w.Header().Get("X-RateLimit-Limit"); got != "3" {
+					t.Fatalf("request %d: X-RateLimit-Limit = %q, want the configured 3", i, got)
+				}
+			}
+		})
+	}
+}
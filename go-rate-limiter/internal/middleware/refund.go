@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/gin-gonic/gin"
+)
+
+// newRefunder returns the function the limiter calls once an admitted
+// request has been handled, or nil unless Options.ChargeOnSuccessOnly or
+// Options.RefundOnCancel is set. It gives the request's units back when
+// the response is a 5xx (ChargeOnSuccessOnly) or the client went away
+// before it completed (RefundOnCancel), so clients are charged neither
+// for the upstream's failures nor for requests they abandoned.
+func newRefunder(opts Options) func(c *gin.Context, mode, key string, cost, limit int) {
+	if !opts.ChargeOnSuccessOnly && !opts.RefundOnCancel {
+		return nil
+	}
+
+	refunds := opts.Store.(ratelimiter.RefundStore) // checked by Validate
+	slog.Info("rate limit refunds enabled", "failed_upstream", opts.ChargeOnSuccessOnly, "client_cancel", opts.RefundOnCancel)
+
+	return func(c *gin.Context, mode, key string, cost, limit int) {
+		failed := opts.ChargeOnSuccessOnly && c.Writer.Status() >= http.StatusInternalServerError
+		canceled := opts.RefundOnCancel && errors.Is(c.Request.Context().Err(), context.Canceled)
+		if !failed && !canceled {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(config.Ctx, opts.Timeout)
+		defer cancel()
+
+		if err := refunds.Refund(ctx, mode, key, cost, limit); err != nil {
+			slog.Error("rate limit refund failed", "mode", mode, "ip", logIP(c), "err", err)
+			return
+		}
+		for _, rule := range opts.Rules {
+			if err := refunds.Refund(ctx, mode, ruleKey(key, rule), cost, rule.Limit); err != nil {
+				slog.Error("rate limit refund failed", "mode", mode, "ip", logIP(c), "err", err)
+				return
+			}
+		}
+		slog.Debug("rate limit refunded", "request_id", GetRequestID(c), "mode", mode,
+			"ip", logIP(c), "status", c.Writer.Status(), "canceled", canceled, "cost", cost)
+	}
+}
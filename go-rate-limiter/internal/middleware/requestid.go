@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID between client, gateway and
+// upstream.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey is the Gin context key the request ID is stored under.
+const RequestIDKey = "request_id"
+
+// maxRequestIDLen bounds an incoming request ID; longer ones are replaced.
+const maxRequestIDLen = 128
+
+// RequestID returns a middleware that gives every request an ID: the
+// client's X-Request-ID when it sends a usable one, a random UUID
+// otherwise. The ID is stored on the context (RequestIDKey), set on the
+// request headers — so the gateway proxy forwards it upstream — and
+// echoed on the response, letting one request be followed through client,
+// gateway and upstream logs.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newUUID()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Request.Header.Set(RequestIDHeader, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the request ID stored on c by RequestID, or "".
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// validRequestID accepts non-empty IDs of printable ASCII without spaces,
+// so a client cannot inject fake fields or lines into the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+)
+
+// ruleKey is the identifier rule's window is counted under for key. The
+// limit is part of it, so two rules over the same window never share a
+// counter.
+func ruleKey(key string, rule Tier) string {
+	return key + ":" + strconv.Itoa(rule.Limit) + "/" + strconv.Itoa(rule.WindowSeconds)
+}
+
+// withRules wraps check so every request is also checked against rules,
+// each in its own bucket: the request is rejected if any check rejects
+// it, and the most restrictive result is reported.
+//
+// The checks run one after another, not in one script, so when a later
+// rule rejects the request the units already charged by the others are
+// refunded. Between the two a concurrent request may see those units
+// counted — the same window ChargeOnSuccessOnly accepts.
+func withRules(check checkFunc, mode string, refunds ratelimiter.RefundStore, rules []Tier) checkFunc {
+	return func(ctx context.Context, id string, limit, windowSeconds, cost int) (*Result, error) {
+		result, err := check(ctx, id, limit, windowSeconds, cost)
+		if err != nil {
+			return nil, err
+		}
+
+		type charge struct {
+			key   string
+			limit int
+		}
+		var charged []charge
+		if result.Allowed {
+			charged = append(charged, charge{id, limit})
+		}
+
+		for _, rule := range rules {
+			key := ruleKey(id, rule)
+			r, err := check(ctx, key, rule.Limit, rule.WindowSeconds, cost)
+			if err != nil {
+				return nil, err
+			}
+			if r.Allowed {
+				charged = append(charged, charge{key, rule.Limit})
+			}
+			result = mostRestrictive(result, r)
+		}
+
+		if !result.Allowed {
+			for _, ch := range charged {
+				if err := refunds.Refund(ctx, mode, ch.key, cost, ch.limit); err != nil {
+					slog.Error("rate limit refund failed", "mode", mode, "key", ch.key, "err", err)
+				}
+			}
+		}
+		return result, nil
+	}
+}
+
+// mostRestrictive returns whichever of a and b binds harder: a rejection
+// over an admission, the longer wait between two rejections, and the
+// fewer units left between two admissions. Ties go to a.
+func mostRestrictive(a, b *Result) *Result {
+	switch {
+	case a.Allowed != b.Allowed:
+		if a.Allowed {
+			return b
+		}
+		return a
+	case !a.Allowed:
+		if b.RetryAfter > a.RetryAfter {
+			return b
+		}
+		return a
+	default:
+		if b.Remaining() < a.Remaining() {
+			return b
+		}
+		return a
+	}
+}
+
+// rulesRefundStore returns opts.Store as a RefundStore, which layered
+// rules need to undo partial charges.
+func rulesRefundStore(opts Options) ratelimiter.RefundStore {
+	refunds := opts.Store.(ratelimiter.RefundStore) // checked by Validate
+	slog.Info("layered rate limits configured", "rules", len(opts.Rules))
+	return refunds
+}
+
+// ParseRules parses a RATE_LIMIT_RULES value such as "10/1,5000/86400" —
+// limit/window_seconds, comma-separated — into Options.Rules.
+func ParseRules(spec string) ([]Tier, error) {
+	var rules []Tier
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		limit, window, ok := strings.Cut(entry, "/")
+		l, err1 := strconv.Atoi(strings.TrimSpace(limit))
+		w, err2 := strconv.Atoi(strings.TrimSpace(window))
+		if !ok || err1 != nil || err2 != nil || l < 1 || w < 1 {
+			return nil, fmt.Errorf("invalid rate limit rule %q: want limit/window_seconds", entry)
+		}
+		rules = append(rules, Tier{Limit: l, WindowSeconds: w})
+	}
+	return rules, nil
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultTenant is the namespace of requests whose tenant is unknown.
+const DefaultTenant = "default"
+
+// HeaderTenant returns a TenantFunc reading the tenant from header, e.g.
+// "X-Tenant-ID". Requests without the header, or naming a tenant not in
+// tenants, belong to DefaultTenant — otherwise a client could escape its
+// limit by sending a new tenant ID with every request. With no tenants
+// listed, every printable value is accepted: only do that when a proxy
+// you control sets the header.
+func HeaderTenant(header string, tenants []string) TenantFunc {
+	known := make(map[string]bool, len(tenants))
+	for _, t := range tenants {
+		known[t] = true
+	}
+
+	return func(c *gin.Context) string {
+		t := strings.TrimSpace(c.GetHeader(header))
+		if !validIdentity(t) || (len(known) > 0 && !known[t]) {
+			return DefaultTenant
+		}
+		return t
+	}
+}
+
+// tenantKey prefixes every identifier keyFunc returns with the request's
+// tenant, so each tenant's counters are kept apart:
+// rate:{tenant:abc:203.0.113.7}. The tenant sits inside the key's hash
+// tag, keeping all of a client's keys in one cluster slot.
+func tenantKey(tenant TenantFunc, keyFunc KeyFunc) KeyFunc {
+	return func(c *gin.Context) string {
+		t := tenant(c)
+		if t == "" {
+			t = DefaultTenant
+		}
+		return "tenant:" + t + ":" + keyFunc(c)
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// Tier is the quota of one pricing plan.
+type Tier struct {
+	Limit         int // max requests allowed per window
+	WindowSeconds int // window duration in seconds
+}
+
+// APIKeyTiers returns a TierFunc that reads the API key from header, maps
+// it to a plan name via keyPlans and applies that plan's Tier from tiers.
+// Requests without a key, with an unknown key, or whose plan has no tier
+// get defaultTier:
+//
+//	middleware.RateLimiterWithOptions(middleware.Options{
+//		KeyFunc: func(c *gin.Context) string { return c.GetHeader("X-API-Key") },
+//		TierFunc: middleware.APIKeyTiers("X-API-Key",
+//			map[string]string{"key-abc": "pro"},
+//			map[string]middleware.Tier{
+//				"pro":        {Limit: 1000, WindowSeconds: 60},
+//				"enterprise": {Limit: 10000, WindowSeconds: 60},
+//			},
+//			middleware.Tier{Limit: 60, WindowSeconds: 60}, // free / anonymous
+//		),
+//	})
+//
+// Pair it with a KeyFunc that counts per API key, so each customer gets
+// their own quota rather than sharing one per IP.
+func APIKeyTiers(header string, keyPlans map[string]string, tiers map[string]Tier, defaultTier Tier) TierFunc {
+	return func(c *gin.Context) (int, int) {
+		if plan, ok := keyPlans[c.GetHeader(header)]; ok {
+			if t, ok := tiers[plan]; ok {
+				return t.Limit, t.WindowSeconds
+			}
+		}
+		return defaultTier.Limit, defaultTier.WindowSeconds
+	}
+}
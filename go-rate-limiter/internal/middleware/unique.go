@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/audit"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultUniqueClientsWindow is Options.UniqueClientsWindow when unset.
+const defaultUniqueClientsWindow = 3600
+
+// newUniqueClientsCheck returns the function the limiter runs before
+// every check, or nil when Options.UniqueClients is unset. Once more than
+// UniqueClients distinct identifiers have reached the route in the
+// window, it rejects newcomers with Options.RejectStatus (429 by default)
+// and reports true; clients already counted are unaffected. A failed
+// lookup lets the request through.
+func newUniqueClientsCheck(opts Options) func(c *gin.Context, id string) bool {
+	if opts.UniqueClients <= 0 {
+		return nil
+	}
+
+	store := opts.Store.(ratelimiter.UniqueClientStore) // checked by Validate
+
+	window := opts.UniqueClientsWindow
+	if window <= 0 {
+		window = defaultUniqueClientsWindow
+	}
+	slog.Info("distinct client limit enabled", "limit", opts.UniqueClients, "window_seconds", window)
+
+	return func(c *gin.Context, id string) bool {
+		// One count per route; requests no route matched (the gateway's
+		// proxy) share a single count.
+		scope := c.FullPath()
+		if scope == "" {
+			scope = "*"
+		}
+
+		ctx, cancel := context.WithTimeout(config.Ctx, opts.Timeout)
+		result, err := store.UniqueClients(ctx, scope, id, opts.UniqueClients, window)
+		cancel()
+		if err != nil {
+			slog.Warn("distinct client check failed", "scope", scope, "err", err)
+			return false
+		}
+		if !result.Exceeded {
+			return false
+		}
+
+		if opts.DryRun {
+			slog.Warn("distinct client limit would_block", "scope", scope, "ip", logIP(c),
+				"clients", result.Count, "limit", result.Limit)
+			return false
+		}
+		slog.Warn("distinct client limit exceeded", "scope", scope, "ip", logIP(c),
+			"clients", result.Count, "limit", result.Limit)
+		c.JSON(opts.RejectStatus, gin.H{"error": "too many distinct clients"})
+		c.Abort()
+		auditBlock(c, opts.Audit, audit.ReasonUniqueClients, id, "", result.Limit, window)
+		return true
+	}
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/config"
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+)
+
+// Validate reports the first setting in opts that RateLimiterWithOptions
+// and QuotaHandler would refuse: an unknown scope, log mode or calendar
+// period, a reject status outside 4xx / 5xx, a limit, window or tier that
+// is not positive, an invalid access-list or proxy entry, or an option
+// the store cannot back. Those constructors panic on such opts, so call
+// Validate first to handle a bad configuration as an error.
+func (opts Options) Validate() error {
+	switch opts.Scope {
+	case "", ScopePerIP, ScopeGlobal:
+	default:
+		return fmt.Errorf(`unknown rate-limit scope %q: use "per_ip" or "global"`, opts.Scope)
+	}
+
+	if opts.RejectStatus != 0 && (opts.RejectStatus < 400 || opts.RejectStatus > 599) {
+		return fmt.Errorf("reject status must be a 4xx or 5xx code, got %d", opts.RejectStatus)
+	}
+
+	settings := Settings{Limit: opts.Limit, WindowSeconds: opts.WindowSeconds}
+	if opts.Live != nil {
+		settings = opts.Live.Load()
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if err := validateTiers(opts); err != nil {
+		return err
+	}
+	if opts.Countries != nil && opts.GeoFunc == nil {
+		return errors.New("country limits are set but no GeoFunc resolves countries")
+	}
+
+	if !validLogMode(opts.LogMode) {
+		return fmt.Errorf(`unknown LOG_MODE %q: use "all", "blocks_only" or "none"`, opts.LogMode)
+	}
+	if opts.CalendarPeriod != "" {
+		if _, _, err := ratelimiter.CalendarBounds(time.Now(), opts.CalendarPeriod); err != nil {
+			return fmt.Errorf("invalid CALENDAR_PERIOD: %w", err)
+		}
+	}
+
+	if _, err := parseIPList(opts.AllowList); err != nil {
+		return fmt.Errorf("allow list: %w", err)
+	}
+	if _, err := parseIPList(opts.BlockList); err != nil {
+		return fmt.Errorf("block list: %w", err)
+	}
+	if opts.IdentityHeader != "" {
+		proxies, err := parseIPList(opts.IdentityProxies)
+		if err != nil {
+			return fmt.Errorf("identity proxies: %w", err)
+		}
+		if len(proxies) == 0 {
+			return fmt.Errorf("identity header %s is set but no proxy is trusted to send it", opts.IdentityHeader)
+		}
+	}
+
+	return validateStore(opts)
+}
+
+// validateStore reports an option in opts that needs a capability its
+// store (Redis by default) lacks.
+func validateStore(opts Options) error {
+	store := opts.Store
+	if store == nil {
+		store = ratelimiter.NewRedisStore(config.RDB)
+	}
+
+	if opts.SuspiciousThreshold > 0 || opts.BanThreshold > 0 {
+		if _, ok := store.(ratelimiter.BreachStore); !ok {
+			return errors.New("the store cannot count breaches, which breach tracking needs")
+		}
+	}
+	if opts.BanThreshold > 0 {
+		if _, ok := store.(ratelimiter.BanStore); !ok {
+			return errors.New("the store cannot ban clients, which BanThreshold needs")
+		}
+	}
+	if opts.UniqueClients > 0 {
+		if _, ok := store.(ratelimiter.UniqueClientStore); !ok {
+			return errors.New("the store cannot count distinct clients, which UniqueClients needs")
+		}
+	}
+	if opts.ChargeOnSuccessOnly || opts.RefundOnCancel {
+		if _, ok := store.(ratelimiter.RefundStore); !ok {
+			return errors.New("the store cannot refund requests, which ChargeOnSuccessOnly / RefundOnCancel need")
+		}
+	}
+	if len(opts.Rules) > 0 {
+		if _, ok := store.(ratelimiter.RefundStore); !ok {
+			return errors.New("the store cannot refund requests, which Rules need")
+		}
+	}
+	return nil
+}
+
+// mustValidate panics if opts fails Validate: a constructor cannot return
+// a usable handler for it, and exiting is the embedding program's call.
+func mustValidate(opts Options) {
+	if err := opts.Validate(); err != nil {
+		panic("middleware: invalid options: " + err.Error())
+	}
+}
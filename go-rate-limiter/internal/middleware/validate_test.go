@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/ratelimiter"
+)
+
+// basicStore has only the Store methods, none of the optional ones.
+type basicStore struct{ ratelimiter.Store }
+
+func TestOptionsValidate(t *testing.T) {
+	valid := func() Options {
+		return Options{Limit: 10, WindowSeconds: 60, Store: ratelimiter.NewMemoryStore()}
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("Validate(valid options) = %v, want nil", err)
+	}
+
+	for name, mutate := range map[string]func(*Options){
+		"scope":          func(o *Options) { o.Scope = "per_user" },
+		"reject status":  func(o *Options) { o.RejectStatus = 200 },
+		"limit":          func(o *Options) { o.Limit = 0 },
+		"window":         func(o *Options) { o.WindowSeconds = -1 },
+		"method limit":   func(o *Options) { o.Methods = map[string]Tier{"POST": {Limit: 0, WindowSeconds: 60}} },
+		"no geo func":    func(o *Options) { o.Countries = map[string]Tier{"CN": {Limit: 1, WindowSeconds: 60}} },
+		"log mode":       func(o *Options) { o.LogMode = "verbose" },
+		"calendar":       func(o *Options) { o.CalendarPeriod = "week" },
+		"allow list":     func(o *Options) { o.AllowList = []string{"10.0.0.300"} },
+		"block list":     func(o *Options) { o.BlockList = []string{"10.0.0.0/33"} },
+		"no proxies":     func(o *Options) { o.IdentityHeader = "X-User-ID" },
+		"ban store":      func(o *Options) { o.Store, o.BanThreshold = basicStore{o.Store}, 5 },
+		"unique store":   func(o *Options) { o.Store, o.UniqueClients = basicStore{o.Store}, 100 },
+		"refund store":   func(o *Options) { o.Store, o.ChargeOnSuccessOnly = basicStore{o.Store}, true },
+		"rules store":    func(o *Options) { o.Store, o.Rules = basicStore{o.Store}, []Tier{{Limit: 5, WindowSeconds: 1}} },
+		"breaches store": func(o *Options) { o.Store, o.SuspiciousThreshold = basicStore{o.Store}, 5 },
+	} {
+		opts := valid()
+		mutate(&opts)
+		if err := opts.Validate(); err == nil {
+			t.Errorf("%s: Validate() = nil, want an error", name)
+		}
+	}
+}
+
+func TestRateLimiterPanicsOnInvalidOptions(t *testing.T) {
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "unknown rate-limit scope") {
+			t.Fatalf("recovered %q, want a panic naming the bad scope", msg)
+		}
+	}()
+	RateLimiterWithOptions(Options{Limit: 10, WindowSeconds: 60, Scope: "per_user", Store: ratelimiter.NewMemoryStore()})
+}
@@ -0,0 +1,17 @@
+// Package pathutil holds URL path helpers shared by the limiter and the
+// gateway.
+package pathutil
+
+import "strings"
+
+// MatchesPrefix reports whether path lies under prefix on a segment
+// boundary, so "/auth" matches "/auth/login" but not "/authors". A prefix
+// ending in "/" matches everything below it.
+func MatchesPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) ||
+		strings.HasSuffix(prefix, "/") ||
+		path[len(prefix)] == '/'
+}
@@ -0,0 +1,23 @@
+package pathutil
+
+import "testing"
+
+func TestMatchesPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		path, prefix string
+		want         bool
+	}{
+		{"/auth", "/auth", true},
+		{"/auth/login", "/auth", true},
+		{"/authors", "/auth", false},
+		{"/auth/", "/auth/", true},
+		{"/auth/login", "/auth/", true},
+		{"/auth", "/auth/", false},
+		{"/anything", "/", true},
+		{"/api", "/api/upload", false},
+	} {
+		if got := MatchesPrefix(tc.path, tc.prefix); got != tc.want {
+			t.Errorf("MatchesPrefix(%q, %q) = %v, want %v", tc.path, tc.prefix, got, tc.want)
+		}
+	}
+}
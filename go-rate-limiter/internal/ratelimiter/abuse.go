@@ -0,0 +1,132 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Abuse Tracking — Count Rejections Per Client, Ban Persistent Offenders
+// ────────────────────────────────────────────────────────────────────────
+//
+// A single 429 is normal; hundreds a minute from one client are an
+// attack or a broken retry loop. Breaches are counted in a fixed window
+// under rate:breach:{id}, reusing the fixed-window script, so tracking
+// costs one O(1) call per rejected request and nothing for allowed ones.
+//
+// A ban is a key under rate:ban:{id} whose TTL is the ban's length:
+// Redis expires it, so bans lift themselves with no cleanup job.
+// ────────────────────────────────────────────────────────────────────────
+
+// breachPrefix is the key prefix rejections are counted under.
+const breachPrefix = "rate:breach:"
+
+// BreachStore is implemented by stores that can count rate-limit
+// rejections per identifier. RedisStore and MemoryStore both implement it.
+type BreachStore interface {
+	// RecordBreach counts one rejection for identifier and returns the
+	// number recorded in the current window of windowSeconds.
+	RecordBreach(ctx context.Context, identifier string, windowSeconds int) (int64, error)
+}
+
+// RecordBreach implements BreachStore with the fixed-window script.
+func (s *RedisStore) RecordBreach(ctx context.Context, identifier string, windowSeconds int) (int64, error) {
+	key := redisKey(breachPrefix, identifier)
+
+	res, err := fixedWindowScript.Run(ctx, s.rdb, []string{key}, windowSeconds, 1).Int64Slice()
+	if err != nil {
+		return 0, fmt.Errorf("breach script error: %w", err)
+	}
+	return res[0], nil
+}
+
+// RecordBreach implements BreachStore, mirroring the Redis fixed window.
+func (m *MemoryStore) RecordBreach(_ context.Context, identifier string, windowSeconds int) (int64, error) {
+	var count int64
+
+	m.with(redisKey(breachPrefix, identifier), func(e *memoryEntry) {
+		now := time.Now()
+		if !now.Before(e.expires) {
+			e.count = 0
+			e.expires = now.Add(time.Duration(windowSeconds) * time.Second)
+			e.evictAt = e.expires
+		}
+		e.count++
+		count = e.count
+	})
+
+	return count, nil
+}
+
+// banPrefix is the key prefix bans are stored under; the key's TTL is
+// the time left on the ban.
+const banPrefix = "rate:ban:"
+
+// BanStore is implemented by stores that can temporarily ban an
+// identifier. RedisStore and MemoryStore both implement it.
+type BanStore interface {
+	// Ban bans identifier for d, replacing any ban already in place.
+	Ban(ctx context.Context, identifier string, d time.Duration) error
+
+	// Banned returns the time left on identifier's ban, or zero when it
+	// is not banned.
+	Banned(ctx context.Context, identifier string) (time.Duration, error)
+}
+
+// banScript stores a ban that expires after ARGV[1] milliseconds.
+var banScript = redis.NewScript(`
+return redis.call("SET", KEYS[1], "1", "PX", ARGV[1])
+`)
+
+// bannedScript returns the ban's remaining milliseconds (≤ 0 = none).
+var bannedScript = redis.NewScript(`
+return redis.call("PTTL", KEYS[1])
+`)
+
+// Ban implements BanStore with a key that expires with the ban.
+func (s *RedisStore) Ban(ctx context.Context, identifier string, d time.Duration) error {
+	key := redisKey(banPrefix, identifier)
+
+	if err := banScript.Run(ctx, s.rdb, []string{key}, max(1, d.Milliseconds())).Err(); err != nil {
+		return fmt.Errorf("ban script error: %w", err)
+	}
+	return nil
+}
+
+// Banned implements BanStore by reading the ban key's TTL.
+func (s *RedisStore) Banned(ctx context.Context, identifier string) (time.Duration, error) {
+	key := redisKey(banPrefix, identifier)
+
+	pttl, err := bannedScript.Run(ctx, s.rdb, []string{key}).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("banned script error: %w", err)
+	}
+	return time.Duration(max(0, pttl)) * time.Millisecond, nil
+}
+
+// Ban implements BanStore with an entry that is evicted when the ban ends.
+func (m *MemoryStore) Ban(_ context.Context, identifier string, d time.Duration) error {
+	m.with(redisKey(banPrefix, identifier), func(e *memoryEntry) {
+		e.expires = time.Now().Add(d)
+		e.evictAt = e.expires
+	})
+	return nil
+}
+
+// Banned implements BanStore. It only reads, so it never creates an entry.
+func (m *MemoryStore) Banned(_ context.Context, identifier string) (time.Duration, error) {
+	key := redisKey(banPrefix, identifier)
+	sh := &m.shards[shardIndex(key)]
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	e, ok := sh.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	return max(0, time.Until(e.expires)), nil
+}
@@ -0,0 +1,181 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Admin — Inspect and Reset One Client's State
+// ────────────────────────────────────────────────────────────────────────
+//
+// Every algorithm keeps its state for an identifier under its own key
+// (rate:fixed:{id}, rate:{id}, …). All of them share the {id} hash tag, so
+// they live in one Redis Cluster slot and a single script can read or
+// delete them together.
+//
+// The reported state is raw — exactly what is stored. Nothing is pruned,
+// refilled or drained first, since the window and rate belong to the
+// limiter, not the store.
+// ────────────────────────────────────────────────────────────────────────
+
+// stateKeys lists the key prefix each mode stores its state under.
+var stateKeys = []struct{ mode, prefix string }{
+	{"fixed", "rate:fixed:"},
+	{"sliding", "rate:"},
+	{"sliding_counter", "rate:counter:"},
+	{"token_bucket", "rate:bucket:"},
+	{"leaky_bucket", "rate:leaky:"},
+	{"calendar", calendarPrefix},
+	{"breaches", breachPrefix},
+	{"ban", banPrefix},
+}
+
+// KeyState is the stored state of one mode's key for an identifier.
+type KeyState struct {
+	Mode  string        // a limiter mode, or "breaches" / "ban" for abuse tracking
+	Count int64         // window count, ZSET members, current counter, tokens left, queue level, breaches or 1 for a ban
+	TTL   time.Duration // time until the key expires
+}
+
+// AdminStore is implemented by stores that can report and clear the state
+// kept for one identifier. RedisStore and MemoryStore both implement it.
+type AdminStore interface {
+	// Inspect returns the state of every mode that holds a key for
+	// identifier; an identifier never seen returns no states.
+	Inspect(ctx context.Context, identifier string) ([]KeyState, error)
+
+	// Reset deletes all state for identifier in every mode, so its next
+	// request starts a fresh window or a full bucket. Breach counts and
+	// any ban are cleared too.
+	Reset(ctx context.Context, identifier string) error
+}
+
+// inspectScript reads every key in KEYS. Returns {count, pttl} per key,
+// with pttl −2 for a key that does not exist.
+//
+// Time complexity per call: O(1) per key
+var inspectScript = redis.NewScript(`
+local out = {}
+
+for i, key in ipairs(KEYS) do
+    local kind  = redis.call("TYPE", key)["ok"]
+    local count = 0
+
+    if kind == "string" then                    -- fixed window  — O(1)
+        count = tonumber(redis.call("GET", key)) or 0
+    elseif kind == "zset" then                  -- sliding window — O(1)
+        count = redis.call("ZCARD", key)
+    elseif kind == "hash" then                  -- counter / buckets — O(1)
+        local f = redis.call("HMGET", key, "cur", "tokens", "level")
+        count = math.floor(tonumber(f[1] or f[2] or f[3]) or 0)
+    end
+
+    out[#out + 1] = count
+    out[#out + 1] = redis.call("PTTL", key)
+end
+
+return out
+`)
+
+// stateKeyNames returns the Redis key of every mode for identifier, in
+// stateKeys order.
+func stateKeyNames(identifier string) []string {
+	keys := make([]string, len(stateKeys))
+	for i, k := range stateKeys {
+		keys[i] = redisKey(k.prefix, identifier)
+	}
+	return keys
+}
+
+// Inspect implements AdminStore with a single read-only script.
+func (s *RedisStore) Inspect(ctx context.Context, identifier string) ([]KeyState, error) {
+	res, err := inspectScript.Run(ctx, s.rdb, stateKeyNames(identifier)).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("inspect script error: %w", err)
+	}
+
+	var states []KeyState
+	for i, k := range stateKeys {
+		count, pttl := res[2*i], res[2*i+1]
+		if pttl == -2 {
+			continue // no such key
+		}
+		states = append(states, KeyState{
+			Mode:  k.mode,
+			Count: count,
+			TTL:   time.Duration(max(0, pttl)) * time.Millisecond,
+		})
+	}
+	return states, nil
+}
+
+// Reset implements AdminStore with one DEL over every mode's key.
+func (s *RedisStore) Reset(ctx context.Context, identifier string) error {
+	if err := resetScript.Run(ctx, s.rdb, stateKeyNames(identifier)).Err(); err != nil {
+		return fmt.Errorf("reset script error: %w", err)
+	}
+	return nil
+}
+
+// resetScript deletes every key in KEYS.
+var resetScript = redis.NewScript(`
+return redis.call("DEL", unpack(KEYS))
+`)
+
+// Inspect implements AdminStore, reading the same fields the Redis script
+// reports.
+func (m *MemoryStore) Inspect(_ context.Context, identifier string) ([]KeyState, error) {
+	now := time.Now()
+
+	var states []KeyState
+	for _, k := range stateKeys {
+		key := redisKey(k.prefix, identifier)
+		sh := &m.shards[shardIndex(key)]
+
+		sh.mu.Lock()
+		e, ok := sh.entries[key]
+		if ok && e.evictAt.After(now) {
+			states = append(states, KeyState{
+				Mode:  k.mode,
+				Count: e.storedCount(k.mode),
+				TTL:   e.evictAt.Sub(now),
+			})
+		}
+		sh.mu.Unlock()
+	}
+	return states, nil
+}
+
+// Reset implements AdminStore by dropping every mode's entry.
+func (m *MemoryStore) Reset(_ context.Context, identifier string) error {
+	for _, k := range stateKeys {
+		key := redisKey(k.prefix, identifier)
+		sh := &m.shards[shardIndex(key)]
+
+		sh.mu.Lock()
+		delete(sh.entries, key)
+		sh.mu.Unlock()
+	}
+	return nil
+}
+
+// storedCount returns the count the entry holds for mode.
+func (e *memoryEntry) storedCount(mode string) int64 {
+	switch mode {
+	case "fixed", "calendar", "breaches":
+		return e.count
+	case "ban":
+		return 1
+	case "sliding":
+		return int64(len(e.stamps))
+	case "sliding_counter":
+		return e.curCount
+	default: // token_bucket, leaky_bucket
+		return int64(math.Floor(e.level))
+	}
+}
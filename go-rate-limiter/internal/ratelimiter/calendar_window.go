@@ -0,0 +1,139 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Calendar-Window Rate Limiter — Quotas That Reset on the Clock
+// ────────────────────────────────────────────────────────────────────────
+//
+// A fixed window opens at a client's first request, so a "daily" quota
+// resets at a different time for every client. Billing quotas reset for
+// everyone at once — at the top of the hour, midnight UTC or the 1st of
+// the month — so this mode ends every window at the next such boundary:
+//   1. INCRBY     the key by the request's cost  →  O(1)
+//   2. PTTL       the key  →  time left until the boundary
+//   3. PEXPIREAT  the boundary, if the key has no TTL (new period)
+//
+// The boundary is computed in Go and passed to the script, so the period
+// arithmetic (month lengths, leap years) stays out of Lua. Boundaries are
+// in UTC. A key lives until the boundary it was created for, so changing
+// the period takes effect from the next one.
+// ────────────────────────────────────────────────────────────────────────
+
+// Calendar periods, for CheckCalendarWindow.
+const (
+	CalendarHour  = "hour"
+	CalendarDay   = "day"
+	CalendarMonth = "month"
+)
+
+// calendarPrefix is the key prefix calendar windows are counted under.
+const calendarPrefix = "rate:calendar:"
+
+// calendarWindowScript performs INCRBY + PEXPIREAT of a new period in a
+// single atomic Lua execution. Returns {counter value, milliseconds until
+// the boundary}.
+var calendarWindowScript = redis.NewScript(`
+local key         = KEYS[1]
+local boundary_ms = tonumber(ARGV[1])
+local cost        = tonumber(ARGV[2])
+
+-- Step 1: Atomically add this request's cost to the counter — O(1)
+local count = redis.call("INCRBY", key, cost)
+
+-- Step 2: Read the time left until the boundary — O(1)
+local ttl = redis.call("PTTL", key)
+
+-- Step 3: A new period (or a key without a TTL) ends at the boundary — O(1)
+if ttl == -1 then
+    redis.call("PEXPIREAT", key, boundary_ms)
+    ttl = redis.call("PTTL", key)
+end
+
+return {count, ttl}
+`)
+
+// CalendarBounds returns the UTC start and end of the calendar period
+// ("hour", "day" or "month") containing t.
+func CalendarBounds(t time.Time, period string) (start, end time.Time, err error) {
+	t = t.UTC()
+	switch period {
+	case CalendarHour:
+		start = t.Truncate(time.Hour)
+		return start, start.Add(time.Hour), nil
+	case CalendarDay:
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1), nil
+	case CalendarMonth:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unknown calendar period %q: want hour, day or month", period)
+}
+
+// CheckCalendarWindow performs an O(1), race-condition-free rate-limit
+// check for identifier in a fixed window that ends at the next UTC
+// boundary of period. The result's WindowSec is the period's length.
+func CheckCalendarWindow(ctx context.Context, rdb RedisRunner, identifier string, limit int, period string, cost int) (*FixedWindowResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+	start, end, err := CalendarBounds(time.Now(), period)
+	if err != nil {
+		return nil, err
+	}
+
+	key := redisKey(calendarPrefix, identifier)
+
+	res, err := calendarWindowScript.Run(ctx, rdb, []string{key},
+		end.UnixMilli(), // ARGV[1]
+		cost,            // ARGV[2]
+	).Int64Slice()
+
+	if err != nil {
+		return nil, fmt.Errorf("calendar window script error: %w", err)
+	}
+
+	count, pttl := res[0], max(0, res[1])
+
+	return newFixedWindowResult(count, limit, int(end.Sub(start)/time.Second), time.Duration(pttl)*time.Millisecond), nil
+}
+
+// CalendarWindow runs CheckCalendarWindow against the store's Redis.
+func (s *RedisStore) CalendarWindow(ctx context.Context, identifier string, limit int, period string, cost int) (*FixedWindowResult, error) {
+	return CheckCalendarWindow(ctx, s.rdb, identifier, limit, period, cost)
+}
+
+// CalendarWindow mirrors CheckCalendarWindow: add cost, starting a new
+// window at the period's boundary.
+func (m *MemoryStore) CalendarWindow(_ context.Context, identifier string, limit int, period string, cost int) (*FixedWindowResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	start, end, err := CalendarBounds(now, period)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *FixedWindowResult
+
+	m.with(redisKey(calendarPrefix, identifier), func(e *memoryEntry) {
+		if !now.Before(e.expires) {
+			e.count = 0
+			e.expires = end
+			e.evictAt = e.expires
+		}
+		e.count += int64(cost)
+
+		result = newFixedWindowResult(e.count, limit, int(end.Sub(start)/time.Second), e.expires.Sub(now))
+	})
+
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// admitConcurrently fires n checks at once from separate goroutines and
+// returns how many were allowed.
+func admitConcurrently(t *testing.T, n int, check func() (bool, error)) int64 {
+	t.Helper()
+
+	var (
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+		allowed atomic.Int64
+		failed  atomic.Int64
+	)
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			ok, err := check()
+			if err != nil {
+				failed.Add(1)
+				return
+			}
+			if ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if f := failed.Load(); f > 0 {
+		t.Fatalf("%d checks failed", f)
+	}
+	return allowed.Load()
+}
+
+func TestCheckFixedWindowConcurrent(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	const limit, callers = 50, 500
+
+	got := admitConcurrently(t, callers, func() (bool, error) {
+		res, err := CheckFixedWindow(context.Background(), rdb, "client", limit, 60, 1)
+		if err != nil {
+			return false, err
+		}
+		return res.Allowed, nil
+	})
+	if got != limit {
+		t.Fatalf("%d of %d concurrent requests admitted, want exactly %d", got, callers, limit)
+	}
+}
+
+func TestCheckSlidingWindowConcurrent(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	const limit, callers = 50, 500
+
+	got := admitConcurrently(t, callers, func() (bool, error) {
+		res, err := CheckSlidingWindow(context.Background(), rdb, "client", limit, 60, 1)
+		if err != nil {
+			return false, err
+		}
+		return res.Allowed, nil
+	})
+	if got != limit {
+		t.Fatalf("%d of %d concurrent requests admitted, want exactly %d", got, callers, limit)
+	}
+}
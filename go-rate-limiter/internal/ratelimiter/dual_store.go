@@ -0,0 +1,322 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Dual Store — Mirror Every Write to a Second Redis During a Migration
+// ────────────────────────────────────────────────────────────────────────
+//
+// Moving to a new Redis normally resets every client's window. DualStore
+// avoids that: the primary decides every check as before, and each write
+// is replayed on the secondary in the background, so after a full window
+// of dual-writing the secondary holds the same state and can become the
+// primary without anyone noticing.
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ BEST EFFORT                                                        │
+// │                                                                    │
+// │  • The secondary never affects a decision or adds latency: its    │
+// │    writes run after the primary answered, off the request path.   │
+// │  • A secondary that is slow or down only loses mirrored writes;   │
+// │    they are reported through onError and never retried.            │
+// │  • At most maxMirrorsInFlight writes are pending at once; beyond  │
+// │    that, writes are dropped rather than piling up goroutines.      │
+// │  • The secondary runs the same checks, so a window it has only    │
+// │    partly seen may reject where the primary allowed — those       │
+// │    requests are then missing there until its windows catch up.    │
+// └────────────────────────────────────────────────────────────────────┘
+//
+// Reads (Inspect, Stats, peeks, bans) are served by the primary only.
+// ────────────────────────────────────────────────────────────────────────
+
+// maxMirrorsInFlight caps the mirrored writes pending on the secondary.
+const maxMirrorsInFlight = 1024
+
+// mirrorTimeout bounds each mirrored write.
+const mirrorTimeout = time.Second
+
+// errMirrorBusy reports a mirrored write dropped because too many were
+// already in flight.
+var errMirrorBusy = errors.New("too many mirrored writes in flight")
+
+// DualStore is a Store that decides with primary and mirrors every write
+// to secondary. It implements the optional store interfaces whenever the
+// primary does; see NewDualStore.
+type DualStore struct {
+	primary   Store
+	secondary Store
+	onError   func(op string, err error)
+	inFlight  chan struct{}
+}
+
+// NewDualStore returns a Store answering from primary and replaying each
+// write on secondary, best effort. onError, if not nil, is called with
+// the operation's name for every mirrored write that failed or was
+// dropped; it may be called from several goroutines at once.
+func NewDualStore(primary, secondary Store, onError func(op string, err error)) *DualStore {
+	if onError == nil {
+		onError = func(string, error) {}
+	}
+	return &DualStore{
+		primary:   primary,
+		secondary: secondary,
+		onError:   onError,
+		inFlight:  make(chan struct{}, maxMirrorsInFlight),
+	}
+}
+
+// mirror runs write against the secondary in the background, detached
+// from ctx's cancellation (the request is usually over by then).
+func (s *DualStore) mirror(ctx context.Context, op string, write func(ctx context.Context) error) {
+	select {
+	case s.inFlight <- struct{}{}:
+	default:
+		s.onError(op, errMirrorBusy)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mirrorTimeout)
+	go func() {
+		defer func() { <-s.inFlight }()
+		defer cancel()
+		if err := write(ctx); err != nil {
+			s.onError(op, err)
+		}
+	}()
+}
+
+// errUnsupported reports an optional interface the primary lacks.
+func errUnsupported(op string) error {
+	return errors.New("the primary store does not support " + op)
+}
+
+// FixedWindow checks the primary and mirrors the charge.
+func (s *DualStore) FixedWindow(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*FixedWindowResult, error) {
+	r, err := s.primary.FixedWindow(ctx, identifier, limit, windowSeconds, cost)
+	if err == nil {
+		s.mirror(ctx, "fixed", func(ctx context.Context) error {
+			_, err := s.secondary.FixedWindow(ctx, identifier, limit, windowSeconds, cost)
+			return err
+		})
+	}
+	return r, err
+}
+
+// SlidingWindow checks the primary and mirrors the charge.
+func (s *DualStore) SlidingWindow(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*SlidingWindowResult, error) {
+	r, err := s.primary.SlidingWindow(ctx, identifier, limit, windowSeconds, cost)
+	if err == nil {
+		s.mirror(ctx, "sliding", func(ctx context.Context) error {
+			_, err := s.secondary.SlidingWindow(ctx, identifier, limit, windowSeconds, cost)
+			return err
+		})
+	}
+	return r, err
+}
+
+// SlidingCounter checks the primary and mirrors the charge.
+func (s *DualStore) SlidingCounter(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*SlidingCounterResult, error) {
+	r, err := s.primary.SlidingCounter(ctx, identifier, limit, windowSeconds, cost)
+	if err == nil {
+		s.mirror(ctx, "sliding_counter", func(ctx context.Context) error {
+			_, err := s.secondary.SlidingCounter(ctx, identifier, limit, windowSeconds, cost)
+			return err
+		})
+	}
+	return r, err
+}
+
+// TokenBucket checks the primary and mirrors the charge.
+func (s *DualStore) TokenBucket(ctx context.Context, identifier string, capacity int, refillPerSec float64, cost int) (*TokenBucketResult, error) {
+	r, err := s.primary.TokenBucket(ctx, identifier, capacity, refillPerSec, cost)
+	if err == nil {
+		s.mirror(ctx, "token_bucket", func(ctx context.Context) error {
+			_, err := s.secondary.TokenBucket(ctx, identifier, capacity, refillPerSec, cost)
+			return err
+		})
+	}
+	return r, err
+}
+
+// LeakyBucket checks the primary and mirrors the charge.
+func (s *DualStore) LeakyBucket(ctx context.Context, identifier string, capacity int, leakRatePerSec float64, cost int) (*LeakyBucketResult, error) {
+	r, err := s.primary.LeakyBucket(ctx, identifier, capacity, leakRatePerSec, cost)
+	if err == nil {
+		s.mirror(ctx, "leaky_bucket", func(ctx context.Context) error {
+			_, err := s.secondary.LeakyBucket(ctx, identifier, capacity, leakRatePerSec, cost)
+			return err
+		})
+	}
+	return r, err
+}
+
+// CalendarWindow checks the primary and mirrors the charge.
+func (s *DualStore) CalendarWindow(ctx context.Context, identifier string, limit int, period string, cost int) (*FixedWindowResult, error) {
+	r, err := s.primary.CalendarWindow(ctx, identifier, limit, period, cost)
+	if err == nil {
+		s.mirror(ctx, "calendar", func(ctx context.Context) error {
+			_, err := s.secondary.CalendarWindow(ctx, identifier, limit, period, cost)
+			return err
+		})
+	}
+	return r, err
+}
+
+// Inspect implements AdminStore from the primary.
+func (s *DualStore) Inspect(ctx context.Context, identifier string) ([]KeyState, error) {
+	admin, ok := s.primary.(AdminStore)
+	if !ok {
+		return nil, errUnsupported("inspection")
+	}
+	return admin.Inspect(ctx, identifier)
+}
+
+// Reset implements AdminStore on the primary and mirrors it.
+func (s *DualStore) Reset(ctx context.Context, identifier string) error {
+	admin, ok := s.primary.(AdminStore)
+	if !ok {
+		return errUnsupported("inspection")
+	}
+	if err := admin.Reset(ctx, identifier); err != nil {
+		return err
+	}
+	if secondary, ok := s.secondary.(AdminStore); ok {
+		s.mirror(ctx, "reset", func(ctx context.Context) error {
+			return secondary.Reset(ctx, identifier)
+		})
+	}
+	return nil
+}
+
+// RecordBreach implements BreachStore on the primary and mirrors it.
+func (s *DualStore) RecordBreach(ctx context.Context, identifier string, windowSeconds int) (int64, error) {
+	breaches, ok := s.primary.(BreachStore)
+	if !ok {
+		return 0, errUnsupported("breach tracking")
+	}
+	n, err := breaches.RecordBreach(ctx, identifier, windowSeconds)
+	if secondary, ok := s.secondary.(BreachStore); ok && err == nil {
+		s.mirror(ctx, "breach", func(ctx context.Context) error {
+			_, err := secondary.RecordBreach(ctx, identifier, windowSeconds)
+			return err
+		})
+	}
+	return n, err
+}
+
+// Ban implements BanStore on the primary and mirrors it.
+func (s *DualStore) Ban(ctx context.Context, identifier string, d time.Duration) error {
+	bans, ok := s.primary.(BanStore)
+	if !ok {
+		return errUnsupported("bans")
+	}
+	if err := bans.Ban(ctx, identifier, d); err != nil {
+		return err
+	}
+	if secondary, ok := s.secondary.(BanStore); ok {
+		s.mirror(ctx, "ban", func(ctx context.Context) error {
+			return secondary.Ban(ctx, identifier, d)
+		})
+	}
+	return nil
+}
+
+// Banned implements BanStore from the primary.
+func (s *DualStore) Banned(ctx context.Context, identifier string) (time.Duration, error) {
+	bans, ok := s.primary.(BanStore)
+	if !ok {
+		return 0, errUnsupported("bans")
+	}
+	return bans.Banned(ctx, identifier)
+}
+
+// Refund implements RefundStore on the primary and mirrors it.
+func (s *DualStore) Refund(ctx context.Context, mode, identifier string, cost, capacity int) error {
+	refunds, ok := s.primary.(RefundStore)
+	if !ok {
+		return errUnsupported("refunds")
+	}
+	if err := refunds.Refund(ctx, mode, identifier, cost, capacity); err != nil {
+		return err
+	}
+	if secondary, ok := s.secondary.(RefundStore); ok {
+		s.mirror(ctx, "refund", func(ctx context.Context) error {
+			return secondary.Refund(ctx, mode, identifier, cost, capacity)
+		})
+	}
+	return nil
+}
+
+// UniqueClients implements UniqueClientStore on the primary and mirrors
+// it.
+func (s *DualStore) UniqueClients(ctx context.Context, scope, identifier string, limit, windowSeconds int) (*UniqueClientsResult, error) {
+	unique, ok := s.primary.(UniqueClientStore)
+	if !ok {
+		return nil, errUnsupported("distinct-client counting")
+	}
+	r, err := unique.UniqueClients(ctx, scope, identifier, limit, windowSeconds)
+	if secondary, ok := s.secondary.(UniqueClientStore); ok && err == nil {
+		s.mirror(ctx, "unique_clients", func(ctx context.Context) error {
+			_, err := secondary.UniqueClients(ctx, scope, identifier, limit, windowSeconds)
+			return err
+		})
+	}
+	return r, err
+}
+
+// Stats implements StatsStore from the primary.
+func (s *DualStore) Stats(ctx context.Context, n int) (*Stats, error) {
+	stats, ok := s.primary.(StatsStore)
+	if !ok {
+		return nil, errUnsupported("stats")
+	}
+	return stats.Stats(ctx, n)
+}
+
+// PeekFixedWindow implements PeekStore from the primary.
+func (s *DualStore) PeekFixedWindow(ctx context.Context, identifier string, limit int, windowSeconds int) (*FixedWindowResult, error) {
+	peek, ok := s.primary.(PeekStore)
+	if !ok {
+		return nil, errUnsupported("peeking")
+	}
+	return peek.PeekFixedWindow(ctx, identifier, limit, windowSeconds)
+}
+
+// PeekSlidingWindow implements PeekStore from the primary.
+func (s *DualStore) PeekSlidingWindow(ctx context.Context, identifier string, limit int, windowSeconds int) (*SlidingWindowResult, error) {
+	peek, ok := s.primary.(PeekStore)
+	if !ok {
+		return nil, errUnsupported("peeking")
+	}
+	return peek.PeekSlidingWindow(ctx, identifier, limit, windowSeconds)
+}
+
+// SlidingLog implements SlidingLogStore from the primary.
+func (s *DualStore) SlidingLog(ctx context.Context, identifier string) ([]time.Time, error) {
+	log, ok := s.primary.(SlidingLogStore)
+	if !ok {
+		return nil, errUnsupported("sliding logs")
+	}
+	return log.SlidingLog(ctx, identifier)
+}
+
+// ReserveFixedWindow implements ReserveStore on the primary and mirrors
+// a granted reservation.
+func (s *DualStore) ReserveFixedWindow(ctx context.Context, identifier string, limit int, windowSeconds int, n int) (int, error) {
+	reserve, ok := s.primary.(ReserveStore)
+	if !ok {
+		return 0, errUnsupported("reservations")
+	}
+	granted, err := reserve.ReserveFixedWindow(ctx, identifier, limit, windowSeconds, n)
+	if secondary, ok := s.secondary.(ReserveStore); ok && err == nil && granted > 0 {
+		s.mirror(ctx, "reserve", func(ctx context.Context) error {
+			_, err := secondary.ReserveFixedWindow(ctx, identifier, limit, windowSeconds, n)
+			return err
+		})
+	}
+	return granted, err
+}
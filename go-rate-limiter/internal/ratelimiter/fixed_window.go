@@ -3,6 +3,9 @@ package ratelimiter
 import (
 	"context"
 	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -12,18 +15,20 @@ import (
 // ────────────────────────────────────────────────────────────────────────
 //
 // Algorithm:
-//   1. INCR  the key  →  O(1) atomic counter increment
-//   2. If counter == 1 (first request in window), set EXPIRE  →  O(1)
-//   3. Compare counter with limit  →  O(1)
+//   1. INCRBY the key by the request's cost  →  O(1) atomic increment
+//   2. PTTL  the key  →  O(1) time left until the window resets
+//   3. If the key has no TTL (new window, or a key left without one),
+//      set EXPIRE  →  O(1)
+//   4. Compare counter with limit  →  O(1)
 //
-// All three steps are packed into a single Lua script that Redis executes
+// All steps are packed into a single Lua script that Redis executes
 // atomically — no other command can interleave between them.
 //
 // ┌────────────────────────────────────────────────────────────────────┐
 // │ WHY O(1)?                                                         │
 // │                                                                    │
 // │  • Redis INCR operates on an integer in constant time.             │
-// │  • Redis EXPIRE sets a TTL in constant time.                       │
+// │  • Redis PTTL / EXPIRE read and set a TTL in constant time.        │
 // │  • The conditional check is a simple integer comparison.           │
 // │  • No loops, no scans, no historical data — regardless of         │
 // │    whether 1 or 1,000,000 requests have been made.                │
@@ -51,57 +56,106 @@ import (
 //   • Stateless app  → horizontally scalable; all state lives in Redis.
 // ────────────────────────────────────────────────────────────────────────
 
-// fixedWindowScript performs INCR + conditional EXPIRE in a single atomic
-// Lua execution. Returns the updated counter value.
+// fixedWindowScript performs INCRBY + conditional EXPIRE in a single
+// atomic Lua execution. Returns {counter value, milliseconds until reset}.
+//
+// The TTL is set whenever the key has none (PTTL = -1), not only on the
+// first increment, so a counter can never outlive its window — even one
+// left without a TTL by a crash or a manual write. Without this, such a
+// key would block its client forever.
 //
 // Time complexity per call: O(1)
 // Race conditions:          None (atomic Lua script)
 var fixedWindowScript = redis.NewScript(`
 local key        = KEYS[1]
 local expire_sec = tonumber(ARGV[1])
+local cost       = tonumber(ARGV[2])
+
+-- Step 1: Atomically add this request's cost to the counter — O(1)
+local count = redis.call("INCRBY", key, cost)
 
--- Step 1: Atomically increment the counter — O(1)
-local count = redis.call("INCR", key)
+-- Step 2: Read the time left in the window — O(1)
+local ttl = redis.call("PTTL", key)
 
--- Step 2: On the very first request in this window, set TTL — O(1)
-if count == 1 then
+-- Step 3: Bound any counter without a TTL (e.g. new window) — O(1)
+if ttl == -1 then
     redis.call("EXPIRE", key, expire_sec)
+    ttl = expire_sec * 1000
 end
 
--- Step 3: Return the counter so Go can compare with the limit — O(1)
-return count
+return {count, ttl}
 `)
 
 // FixedWindowResult holds the outcome of a fixed-window rate-limit check.
 type FixedWindowResult struct {
-	Allowed   bool  // whether the request should be forwarded
-	Count     int64 // current request count inside the window
-	Limit     int   // configured maximum requests per window
-	WindowSec int   // window duration in seconds
+	Allowed    bool          // whether the request should be forwarded
+	Count      int64         // units consumed inside the window (requests × cost)
+	Limit      int           // configured maximum requests per window
+	WindowSec  int           // window duration in seconds
+	Reset      time.Time     // when the current window expires
+	RetryAfter time.Duration // time until a slot frees up; zero when allowed
 }
 
 // CheckFixedWindow performs an O(1), race-condition-free rate-limit check
-// for the given identifier using the fixed-window counter algorithm.
+// for the given identifier using the fixed-window counter algorithm. The
+// request consumes cost units of the window's limit (1 for a plain count).
 //
 // Guarantees:
-//   - O(1) time complexity: uses only Redis INCR and EXPIRE.
+//   - O(1) time complexity: uses only Redis INCRBY and EXPIRE.
 //   - Zero race conditions: all operations run in a single atomic Lua script.
 //   - Safe at any scale: 1 or 100,000 concurrent callers see consistent results.
-func CheckFixedWindow(ctx context.Context, rdb *redis.Client, identifier string, limit int, windowSeconds int) (*FixedWindowResult, error) {
-	key := "rate:fixed:" + identifier
+func CheckFixedWindow(ctx context.Context, rdb RedisRunner, identifier string, limit int, windowSeconds int, cost int) (*FixedWindowResult, error) {
+	return checkFixedWindow(ctx, rdb, identifier, limit, windowSeconds, cost, 0)
+}
+
+// checkFixedWindow is CheckFixedWindow with each new window's TTL spread
+// by ±jitter (a fraction of the window, see jitterSeconds).
+func checkFixedWindow(ctx context.Context, rdb RedisRunner, identifier string, limit int, windowSeconds int, cost int, jitter float64) (*FixedWindowResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+
+	key := redisKey("rate:fixed:", identifier)
 
-	count, err := fixedWindowScript.Run(ctx, rdb, []string{key},
-		windowSeconds, // ARGV[1]
-	).Int64()
+	res, err := fixedWindowScript.Run(ctx, rdb, []string{key},
+		jitterSeconds(windowSeconds, jitter), // ARGV[1]
+		cost,                                 // ARGV[2]
+	).Int64Slice()
 
 	if err != nil {
 		return nil, fmt.Errorf("fixed window script error: %w", err)
 	}
 
-	return &FixedWindowResult{
+	count, pttl := res[0], res[1]
+
+	return newFixedWindowResult(count, limit, windowSeconds, time.Duration(pttl)*time.Millisecond), nil
+}
+
+// jitterSeconds returns sec spread uniformly by ±jitter (e.g. 0.1 for
+// ±10%), never below one second. When many clients open their windows
+// together, jittered TTLs make their keys expire — and their budgets
+// reset — at different moments instead of in one synchronised burst.
+func jitterSeconds(sec int, jitter float64) int {
+	if jitter <= 0 {
+		return sec
+	}
+	spread := float64(sec) * jitter * (2*rand.Float64() - 1)
+	return max(1, int(math.Round(float64(sec)+spread)))
+}
+
+// newFixedWindowResult builds the result for a window holding count
+// requests that expires after ttl.
+func newFixedWindowResult(count int64, limit int, windowSeconds int, ttl time.Duration) *FixedWindowResult {
+	result := &FixedWindowResult{
 		Allowed:   count <= int64(limit),
 		Count:     count,
 		Limit:     limit,
 		WindowSec: windowSeconds,
-	}, nil
+		Reset:     time.Now().Add(ttl),
+	}
+	if !result.Allowed {
+		result.RetryAfter = ttl // the whole counter resets when the key expires
+	}
+
+	return result
 }
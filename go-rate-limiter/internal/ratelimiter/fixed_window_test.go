@@ -0,0 +1,150 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCheckFixedWindow(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+	const limit, window = 3, 10
+
+	for i := 1; i <= limit; i++ {
+		res, err := CheckFixedWindow(ctx, rdb, "client", limit, window, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Count != int64(i) {
+			t.Fatalf("request %d: allowed=%v count=%d, want allowed with count %d", i, res.Allowed, res.Count, i)
+		}
+		if i == 1 {
+			if ttl := mr.TTL("rate:fixed:{client}"); ttl != window*time.Second {
+				t.Fatalf("first hit set TTL %v, want %v", ttl, window*time.Second)
+			}
+		}
+	}
+
+	res, err := CheckFixedWindow(ctx, rdb, "client", limit, window, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed {
+		t.Fatalf("request %d allowed, want blocked", limit+1)
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > window*time.Second {
+		t.Fatalf("RetryAfter = %v, want within (0, %ds]", res.RetryAfter, window)
+	}
+
+	mr.FastForward(window * time.Second)
+
+	res, err = CheckFixedWindow(ctx, rdb, "client", limit, window, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed || res.Count != 1 {
+		t.Fatalf("after the window: allowed=%v count=%d, want a fresh window", res.Allowed, res.Count)
+	}
+}
+
+func TestCheckFixedWindowCost(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	res, err := CheckFixedWindow(ctx, rdb, "client", 5, 10, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed || res.Count != 4 {
+		t.Fatalf("allowed=%v count=%d, want allowed with count 4", res.Allowed, res.Count)
+	}
+
+	res, err = CheckFixedWindow(ctx, rdb, "client", 5, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed {
+		t.Fatal("a request overshooting the limit was allowed")
+	}
+}
+
+// A counter left without a TTL, by a crash or a manual write, must get one
+// on its next hit instead of blocking its client forever.
+func TestCheckFixedWindowRestoresMissingTTL(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	const key = "rate:fixed:{client}"
+
+	if err := mr.Set(key, "7"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL(key); ttl != 0 {
+		t.Fatalf("setup: key has TTL %v, want none", ttl)
+	}
+
+	res, err := CheckFixedWindow(context.Background(), rdb, "client", 5, 30, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed || res.Count != 8 {
+		t.Fatalf("allowed=%v count=%d, want blocked with count 8", res.Allowed, res.Count)
+	}
+	if ttl := mr.TTL(key); ttl != 30*time.Second {
+		t.Fatalf("TTL after the hit = %v, want 30s", ttl)
+	}
+	if res.RetryAfter != 30*time.Second {
+		t.Fatalf("RetryAfter = %v, want 30s", res.RetryAfter)
+	}
+}
+
+func TestJitterSeconds(t *testing.T) {
+	if got := jitterSeconds(60, 0); got != 60 {
+		t.Fatalf("jitterSeconds(60, 0) = %d, want 60 unchanged", got)
+	}
+
+	lo, hi := 100, 100
+	for range 10000 {
+		got := jitterSeconds(100, 0.1)
+		if got < 90 || got > 110 {
+			t.Fatalf("jitterSeconds(100, 0.1) = %d, outside [90, 110]", got)
+		}
+		lo, hi = min(lo, got), max(hi, got)
+	}
+	if lo == 100 || hi == 100 {
+		t.Fatalf("jitter only spread to [%d, %d], want both sides of 100", lo, hi)
+	}
+
+	for range 1000 {
+		if got := jitterSeconds(1, 0.9); got < 1 {
+			t.Fatalf("jitterSeconds(1, 0.9) = %d, want at least 1", got)
+		}
+	}
+}
+
+func TestRedisStoreTTLJitter(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	store := NewRedisStore(rdb).WithTTLJitter(0.1)
+	ctx := context.Background()
+
+	for i := range 200 {
+		id := fmt.Sprintf("client-%d", i)
+		if _, err := store.FixedWindow(ctx, id, 10, 100, 1); err != nil {
+			t.Fatal(err)
+		}
+		if ttl := mr.TTL("rate:fixed:{" + id + "}"); ttl < 90*time.Second || ttl > 110*time.Second {
+			t.Fatalf("TTL %v outside the ±10%% band of 100s", ttl)
+		}
+	}
+}
+
+func BenchmarkFixedWindow(b *testing.B) {
+	_, rdb := newTestRedis(b)
+	ctx := context.Background()
+
+	benchmarkCheck(b, func(identifier string) error {
+		_, err := CheckFixedWindow(ctx, rdb, identifier, math.MaxInt32, 60, 1)
+		return err
+	})
+}
@@ -0,0 +1,128 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Leaky-Bucket Rate Limiter — Constant Drain, Atomic Lua Script
+// ────────────────────────────────────────────────────────────────────────
+//
+// Algorithm (Redis Hash, "leaky bucket as a meter"):
+//   1. HMGET   → read the stored queue level and last-leak timestamp
+//   2. Leak    → drain (elapsed × leak rate) from the level, floor at 0
+//   3. Admit   → if level + cost fits in capacity, add the request
+//   4. HSET    → persist the new level and timestamp
+//   5. EXPIRE  → drop the key once the queue would be fully drained
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ WHY O(1)?                                                         │
+// │                                                                    │
+// │  • The queue is modelled as a single number, not a list of        │
+// │    requests — two hash fields per client, fixed work per call.    │
+// └────────────────────────────────────────────────────────────────────┘
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ ZERO RACE CONDITIONS                                               │
+// │                                                                    │
+// │  • Read-leak-admit-write runs inside a single Lua script, so the  │
+// │    queue level can never be overfilled by concurrent callers.     │
+// └────────────────────────────────────────────────────────────────────┘
+//
+// Trade-off vs Token Bucket:
+//   • Token bucket lets a quiet client burst; leaky bucket does not
+//     reward idleness beyond an empty queue, so output is smoother.
+//   • Best suited to shaping traffic toward a downstream service that
+//     cannot tolerate bursts.
+// ────────────────────────────────────────────────────────────────────────
+
+// leakyBucketScript drains and fills a virtual queue stored in a Redis
+// hash. Returns {allowed (0|1), queue level rounded up}.
+//
+// Time complexity per call: O(1)
+// Race conditions:          None (atomic Lua script)
+var leakyBucketScript = redis.NewScript(`
+local key          = KEYS[1]
+local capacity     = tonumber(ARGV[1])
+local leak_rate    = tonumber(ARGV[2])   -- requests drained per second
+local now          = tonumber(ARGV[3])   -- milliseconds
+local expire_sec   = tonumber(ARGV[4])
+local cost         = tonumber(ARGV[5])
+
+-- 1. Load the queue; a missing key is an empty queue  — O(1)
+local bucket = redis.call("HMGET", key, "level", "ts")
+local level  = tonumber(bucket[1]) or 0
+local ts     = tonumber(bucket[2]) or now
+
+-- 2. Drain what has leaked out since the last call    — O(1)
+local elapsed = math.max(0, now - ts)
+level = math.max(0, level - (elapsed / 1000) * leak_rate)
+
+-- 3. Admit the request if the queue has room for it   — O(1)
+local allowed = 0
+if level + cost <= capacity then
+    level   = level + cost
+    allowed = 1
+end
+
+-- 4. Persist state and refresh TTL                    — O(1)
+redis.call("HSET", key, "level", level, "ts", now)
+redis.call("EXPIRE", key, expire_sec)
+
+return {allowed, math.ceil(level)}
+`)
+
+// LeakyBucketResult holds the outcome of a leaky-bucket rate-limit check.
+type LeakyBucketResult struct {
+	Allowed        bool    // whether the request should be forwarded
+	Level          int64   // queue level after this request (rounded up)
+	Capacity       int     // maximum queue size
+	LeakRatePerSec float64 // requests drained from the queue per second
+}
+
+// CheckLeakyBucket performs a leaky-bucket rate-limit check for the given
+// identifier. The bucket is a virtual queue of size capacity draining at
+// leakRatePerSec; a request occupies cost slots (1 for a plain count) and
+// is admitted only if the queue has room for all of them.
+//
+// Guarantees:
+//   - O(1) time complexity: one hash read and write per request.
+//   - Zero race conditions: all operations run in a single atomic Lua script.
+//   - Admitted traffic never exceeds capacity + leakRatePerSec × elapsed.
+func CheckLeakyBucket(ctx context.Context, rdb RedisRunner, identifier string, capacity int, leakRatePerSec float64, cost int) (*LeakyBucketResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+	if leakRatePerSec <= 0 {
+		return nil, fmt.Errorf("leaky bucket leak rate must be positive, got %v", leakRatePerSec)
+	}
+
+	now := time.Now().UnixMilli()                                       // millisecond precision
+	expireSec := int64(math.Ceil(float64(capacity)/leakRatePerSec)) + 1 // time to drain a full queue
+
+	key := redisKey("rate:leaky:", identifier)
+
+	res, err := leakyBucketScript.Run(ctx, rdb, []string{key},
+		capacity,       // ARGV[1]
+		leakRatePerSec, // ARGV[2]
+		now,            // ARGV[3]
+		expireSec,      // ARGV[4]
+		cost,           // ARGV[5]
+	).Int64Slice()
+
+	if err != nil {
+		return nil, fmt.Errorf("leaky bucket script error: %w", err)
+	}
+
+	return &LeakyBucketResult{
+		Allowed:        res[0] == 1,
+		Level:          res[1],
+		Capacity:       capacity,
+		LeakRatePerSec: leakRatePerSec,
+	}, nil
+}
@@ -0,0 +1,180 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Limiter applies one rate limit to any number of keys, independent of
+// Gin or HTTP, so the same Store-backed limit can guard gRPC handlers,
+// background workers or plain net/http servers:
+//
+//	limiter, err := ratelimiter.NewLimiter(ratelimiter.NewRedisStore(rdb), 100, 60, "sliding")
+//	…
+//	ok, err := limiter.Allow(ctx, userID)
+//
+// A Limiter holds no state of its own and is safe for concurrent use.
+type Limiter struct {
+	store         Store
+	limit         int
+	windowSeconds int
+	mode          string
+	period        string // calendar mode only
+}
+
+// Decision is the outcome of one Limiter check, normalised across modes.
+type Decision struct {
+	Allowed    bool          // whether the request may proceed
+	Count      int64         // units counted (or bucket slots used) so far
+	Limit      int           // configured max requests per window / capacity
+	WindowSec  int           // window duration in seconds
+	Reset      time.Time     // when the window frees up; zero for bucket modes
+	RetryAfter time.Duration // time until a slot frees up; zero when allowed or unknown
+}
+
+// Remaining returns how many units are left in the window (or bucket),
+// never below zero.
+func (d *Decision) Remaining() int64 {
+	return max(0, int64(d.Limit)-d.Count)
+}
+
+// NewLimiter returns a Limiter allowing limit requests per windowSeconds
+// for each key, using the algorithm named by mode: "fixed", "sliding"
+// (also the default when empty), "sliding_counter", "token_bucket" or
+// "leaky_bucket". The bucket modes hold limit tokens / slots and refill /
+// drain at limit per windowSeconds. "calendar" counts limit requests per
+// UTC day, ignoring windowSeconds; see NewCalendarLimiter for other periods.
+func NewLimiter(store Store, limit int, windowSeconds int, mode string) (*Limiter, error) {
+	switch mode {
+	case "":
+		mode = "sliding"
+	case "fixed", "sliding", "sliding_counter", "token_bucket", "leaky_bucket":
+	case "calendar":
+		return NewCalendarLimiter(store, limit, CalendarDay)
+	default:
+		return nil, fmt.Errorf("unknown rate-limit mode %q", mode)
+	}
+	if limit < 1 || windowSeconds < 1 {
+		return nil, fmt.Errorf("limit and window must be positive, got %d per %ds", limit, windowSeconds)
+	}
+
+	return &Limiter{store: store, limit: limit, windowSeconds: windowSeconds, mode: mode}, nil
+}
+
+// NewCalendarLimiter returns a Limiter allowing limit requests per
+// calendar period — CalendarHour, CalendarDay or CalendarMonth, in UTC —
+// for each key. Every key's window resets at the same boundary.
+func NewCalendarLimiter(store Store, limit int, period string) (*Limiter, error) {
+	start, end, err := CalendarBounds(time.Now(), period)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+
+	windowSeconds := int(end.Sub(start) / time.Second)
+	return &Limiter{store: store, limit: limit, windowSeconds: windowSeconds, mode: "calendar", period: period}, nil
+}
+
+// Allow reports whether one more request for key fits the limit, and
+// records it if so.
+func (l *Limiter) Allow(ctx context.Context, key string) (bool, error) {
+	d, err := l.Check(ctx, key, 1)
+	if err != nil {
+		return false, err
+	}
+	return d.Allowed, nil
+}
+
+// Check charges cost units for key and returns the full decision, for
+// callers that report the remaining quota or a retry delay.
+func (l *Limiter) Check(ctx context.Context, key string, cost int) (*Decision, error) {
+	switch l.mode {
+	case "fixed":
+		r, err := l.store.FixedWindow(ctx, key, l.limit, l.windowSeconds, cost)
+		if err != nil {
+			return nil, err
+		}
+		return &Decision{
+			Allowed:    r.Allowed,
+			Count:      r.Count,
+			Limit:      r.Limit,
+			WindowSec:  r.WindowSec,
+			Reset:      r.Reset,
+			RetryAfter: r.RetryAfter,
+		}, nil
+
+	case "calendar":
+		r, err := l.store.CalendarWindow(ctx, key, l.limit, l.period, cost)
+		if err != nil {
+			return nil, err
+		}
+		return &Decision{
+			Allowed:    r.Allowed,
+			Count:      r.Count,
+			Limit:      r.Limit,
+			WindowSec:  r.WindowSec,
+			Reset:      r.Reset,
+			RetryAfter: r.RetryAfter,
+		}, nil
+
+	case "sliding_counter":
+		r, err := l.store.SlidingCounter(ctx, key, l.limit, l.windowSeconds, cost)
+		if err != nil {
+			return nil, err
+		}
+		return &Decision{
+			Allowed:    r.Allowed,
+			Count:      r.Count,
+			Limit:      r.Limit,
+			WindowSec:  r.WindowSec,
+			Reset:      r.Reset,
+			RetryAfter: r.RetryAfter,
+		}, nil
+
+	case "token_bucket":
+		r, err := l.store.TokenBucket(ctx, key, l.limit, l.ratePerSec(), cost)
+		if err != nil {
+			return nil, err
+		}
+		return &Decision{
+			Allowed:   r.Allowed,
+			Count:     int64(r.Capacity) - r.Tokens,
+			Limit:     r.Capacity,
+			WindowSec: l.windowSeconds,
+		}, nil
+
+	case "leaky_bucket":
+		r, err := l.store.LeakyBucket(ctx, key, l.limit, l.ratePerSec(), cost)
+		if err != nil {
+			return nil, err
+		}
+		return &Decision{
+			Allowed:   r.Allowed,
+			Count:     r.Level,
+			Limit:     r.Capacity,
+			WindowSec: l.windowSeconds,
+		}, nil
+
+	default:
+		r, err := l.store.SlidingWindow(ctx, key, l.limit, l.windowSeconds, cost)
+		if err != nil {
+			return nil, err
+		}
+		return &Decision{
+			Allowed:    r.Allowed,
+			Count:      r.Count,
+			Limit:      r.Limit,
+			WindowSec:  r.WindowSec,
+			Reset:      r.Reset,
+			RetryAfter: r.RetryAfter,
+		}, nil
+	}
+}
+
+// ratePerSec is the bucket refill / drain rate: limit per window.
+func (l *Limiter) ratePerSec() float64 {
+	return float64(l.limit) / float64(l.windowSeconds)
+}
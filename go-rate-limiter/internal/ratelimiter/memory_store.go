@@ -0,0 +1,344 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// In-Memory Store — Single-Instance Deployments, No Redis
+// ────────────────────────────────────────────────────────────────────────
+//
+// MemoryStore mirrors the Redis Lua scripts in plain Go, so every mode
+// behaves identically whichever backend is selected.
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ CONCURRENCY                                                        │
+// │                                                                    │
+// │  • Keys are spread over memoryShards independent maps, each with  │
+// │    its own mutex — there is no global lock, so unrelated clients  │
+// │    almost never contend.                                           │
+// │  • A check holds its shard's lock for the whole read-modify-write, │
+// │    giving the same atomicity as a Lua script.                      │
+// └────────────────────────────────────────────────────────────────────┘
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ MEMORY                                                             │
+// │                                                                    │
+// │  • Every entry records when it stops mattering (window end, or    │
+// │    bucket full / drained). A background sweep deletes such        │
+// │    entries every evictInterval — the equivalent of Redis TTLs.    │
+// └────────────────────────────────────────────────────────────────────┘
+//
+// Counters are not shared between processes: use Redis as soon as more
+// than one GoShield instance sits behind a load balancer.
+// ────────────────────────────────────────────────────────────────────────
+
+const (
+	memoryShards  = 64               // number of independently locked maps
+	evictInterval = 10 * time.Second // how often expired entries are swept
+)
+
+// MemoryStore is an in-process Store for single-instance deployments and
+// local development.
+type MemoryStore struct {
+	shards [memoryShards]memoryShard
+	done   chan struct{}
+	once   sync.Once
+}
+
+// memoryShard is one independently locked slice of the key space.
+type memoryShard struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// memoryEntry is the in-memory equivalent of one Redis key.
+type memoryEntry struct {
+	evictAt time.Time // when the entry can be dropped (its "TTL")
+
+	count   int64     // fixed window: requests in the current window
+	expires time.Time // fixed window: when the current window ends
+
+	stamps []int64 // sliding window: request timestamps (µs), oldest first
+
+	winStart  int64 // sliding counter: current window start (ms)
+	curCount  int64 // sliding counter: units admitted in the current window
+	prevCount int64 // sliding counter: units admitted in the previous window
+
+	level float64   // token / leaky bucket: tokens left or queue level
+	last  time.Time // token / leaky bucket: last refill or leak; zero = new
+
+	members map[string]struct{} // unique clients: identifiers in the window
+}
+
+// NewMemoryStore returns an empty in-process Store and starts its
+// background eviction loop. Call Close to stop it.
+func NewMemoryStore() *MemoryStore {
+	m := &MemoryStore{done: make(chan struct{})}
+	for i := range m.shards {
+		m.shards[i].entries = make(map[string]*memoryEntry)
+	}
+
+	go m.evictLoop()
+	return m
+}
+
+// Close stops the background eviction loop. The store remains usable.
+func (m *MemoryStore) Close() {
+	m.once.Do(func() { close(m.done) })
+}
+
+// evictLoop periodically drops entries whose state has expired.
+func (m *MemoryStore) evictLoop() {
+	ticker := time.NewTicker(evictInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			m.evictExpired(now)
+		}
+	}
+}
+
+// evictExpired deletes every entry whose evictAt is before now, one shard
+// at a time so checks on other shards are never blocked.
+func (m *MemoryStore) evictExpired(now time.Time) {
+	for i := range m.shards {
+		sh := &m.shards[i]
+		sh.mu.Lock()
+		for key, e := range sh.entries {
+			if e.evictAt.Before(now) {
+				delete(sh.entries, key)
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// with runs fn on the entry for key while holding its shard's lock,
+// creating the entry on first use.
+func (m *MemoryStore) with(key string, fn func(e *memoryEntry)) {
+	sh := &m.shards[shardIndex(key)]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	e, ok := sh.entries[key]
+	if !ok {
+		e = &memoryEntry{}
+		sh.entries[key] = e
+	}
+	fn(e)
+}
+
+// shardIndex hashes key onto a shard with inline FNV-1a (no allocation).
+func shardIndex(key string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return h % memoryShards
+}
+
+// FixedWindow mirrors CheckFixedWindow: add cost, starting a new window
+// when the previous one has expired.
+func (m *MemoryStore) FixedWindow(_ context.Context, identifier string, limit int, windowSeconds int, cost int) (*FixedWindowResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+
+	var result *FixedWindowResult
+
+	m.with(redisKey("rate:fixed:", identifier), func(e *memoryEntry) {
+		now := time.Now()
+		if !now.Before(e.expires) {
+			e.count = 0
+			e.expires = now.Add(time.Duration(windowSeconds) * time.Second)
+			e.evictAt = e.expires
+		}
+		e.count += int64(cost)
+
+		result = newFixedWindowResult(e.count, limit, windowSeconds, e.expires.Sub(now))
+	})
+
+	return result, nil
+}
+
+// SlidingWindow mirrors CheckSlidingWindow: prune timestamps that have aged
+// out, then record this request once per unit of cost if it fits.
+func (m *MemoryStore) SlidingWindow(_ context.Context, identifier string, limit int, windowSeconds int, cost int) (*SlidingWindowResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+
+	var result *SlidingWindowResult
+
+	m.with(redisKey("rate:", identifier), func(e *memoryEntry) {
+		now := time.Now().UnixMicro()
+		windowUs := int64(windowSeconds) * 1e6
+
+		i := 0
+		for i < len(e.stamps) && e.stamps[i] <= now-windowUs {
+			i++
+		}
+		e.stamps = e.stamps[i:]
+		if len(e.stamps) > limit {
+			e.stamps = e.stamps[len(e.stamps)-max(0, limit):] // keep the newest limit
+		}
+
+		allowed := len(e.stamps)+cost <= limit
+		if allowed {
+			for range cost {
+				e.stamps = append(e.stamps, now)
+			}
+		}
+		e.evictAt = time.UnixMicro(now + windowUs)
+
+		count := len(e.stamps)
+		resetUs := now
+		if count > 0 {
+			k := 0
+			if !allowed {
+				k = min(count+cost-limit, count) - 1
+			}
+			resetUs = e.stamps[k] + windowUs
+		}
+		result = newSlidingWindowResult(allowed, int64(count), limit, windowSeconds, now, resetUs)
+	})
+
+	return result, nil
+}
+
+// SlidingCounter mirrors CheckSlidingCounter: roll the two windows forward,
+// blend them, and admit the request if its cost still fits.
+func (m *MemoryStore) SlidingCounter(_ context.Context, identifier string, limit int, windowSeconds int, cost int) (*SlidingCounterResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+
+	var result *SlidingCounterResult
+
+	m.with(redisKey("rate:counter:", identifier), func(e *memoryEntry) {
+		now := time.Now().UnixMilli()
+		windowMs := int64(windowSeconds) * 1000
+
+		start := now - now%windowMs
+		if e.winStart != start {
+			if e.winStart == start-windowMs {
+				e.prevCount = e.curCount
+			} else {
+				e.prevCount = 0
+			}
+			e.curCount = 0
+			e.winStart = start
+		}
+
+		weight := 1 - float64(now-start)/float64(windowMs)
+		allowed := float64(e.prevCount)*weight+float64(e.curCount)+float64(cost) <= float64(limit)
+		if allowed {
+			e.curCount += int64(cost)
+		}
+		e.evictAt = time.UnixMilli(start + 2*windowMs)
+
+		result = newSlidingCounterResult(allowed, e.curCount, e.prevCount, start, now, limit, windowSeconds, cost)
+	})
+
+	return result, nil
+}
+
+// TokenBucket mirrors CheckTokenBucket: refill for the elapsed time, then
+// consume cost tokens if available.
+func (m *MemoryStore) TokenBucket(_ context.Context, identifier string, capacity int, refillPerSec float64, cost int) (*TokenBucketResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+	if refillPerSec <= 0 {
+		return nil, fmt.Errorf("token bucket refill rate must be positive, got %v", refillPerSec)
+	}
+
+	result := &TokenBucketResult{Capacity: capacity, RefillPerSec: refillPerSec}
+
+	m.with(redisKey("rate:bucket:", identifier), func(e *memoryEntry) {
+		now := time.Now()
+		if e.last.IsZero() {
+			e.level, e.last = float64(capacity), now // a new bucket starts full
+		}
+		elapsed := math.Max(0, now.Sub(e.last).Seconds())
+		e.level = math.Min(float64(capacity), e.level+elapsed*refillPerSec)
+		e.last = now
+
+		if e.level >= float64(cost) {
+			e.level -= float64(cost)
+			result.Allowed = true
+		}
+		e.evictAt = now.Add(secondsToDuration(float64(capacity) / refillPerSec)) // full again
+
+		result.Tokens = int64(math.Floor(e.level))
+	})
+
+	return result, nil
+}
+
+// LeakyBucket mirrors CheckLeakyBucket: drain for the elapsed time, then
+// admit the request if the queue has room for cost more slots.
+func (m *MemoryStore) LeakyBucket(_ context.Context, identifier string, capacity int, leakRatePerSec float64, cost int) (*LeakyBucketResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+	if leakRatePerSec <= 0 {
+		return nil, fmt.Errorf("leaky bucket leak rate must be positive, got %v", leakRatePerSec)
+	}
+
+	result := &LeakyBucketResult{Capacity: capacity, LeakRatePerSec: leakRatePerSec}
+
+	m.with(redisKey("rate:leaky:", identifier), func(e *memoryEntry) {
+		now := time.Now()
+		if !e.last.IsZero() {
+			elapsed := math.Max(0, now.Sub(e.last).Seconds())
+			e.level = math.Max(0, e.level-elapsed*leakRatePerSec)
+		}
+		e.last = now
+
+		if e.level+float64(cost) <= float64(capacity) {
+			e.level += float64(cost)
+			result.Allowed = true
+		}
+		e.evictAt = now.Add(secondsToDuration(e.level / leakRatePerSec)) // fully drained
+
+		result.Level = int64(math.Ceil(e.level))
+	})
+
+	return result, nil
+}
+
+// secondsToDuration converts fractional seconds to a time.Duration.
+func secondsToDuration(sec float64) time.Duration {
+	return time.Duration(sec * float64(time.Second))
+}
+
+// ── Package-level local limiter ───────────────────────────────────────
+
+// localStore backs CheckFixedWindowLocal and CheckSlidingWindowLocal.
+var localStore = sync.OnceValue(NewMemoryStore)
+
+// CheckFixedWindowLocal is the in-process counterpart of CheckFixedWindow,
+// backed by a package-wide MemoryStore. Use it when a single instance
+// needs rate limiting without a Redis dependency.
+func CheckFixedWindowLocal(identifier string, limit int, windowSeconds int) *FixedWindowResult {
+	result, _ := localStore().FixedWindow(context.Background(), identifier, limit, windowSeconds, 1)
+	return result
+}
+
+// CheckSlidingWindowLocal is the in-process counterpart of
+// CheckSlidingWindow, backed by a package-wide MemoryStore.
+func CheckSlidingWindowLocal(identifier string, limit int, windowSeconds int) *SlidingWindowResult {
+	result, _ := localStore().SlidingWindow(context.Background(), identifier, limit, windowSeconds, 1)
+	return result
+}
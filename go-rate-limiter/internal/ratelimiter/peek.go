@@ -0,0 +1,180 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Peek — Read a Client's Usage Without Spending Any of It
+// ────────────────────────────────────────────────────────────────────────
+//
+// A quota endpoint built on the normal checks would charge the client for
+// asking how much it has left. The peek scripts only read: no INCR, no
+// ZADD, no EXPIRE, and entries that aged out of a sliding window are
+// skipped (ZCOUNT) rather than pruned. The results mirror the checks'
+// with Allowed meaning "one more request would fit now".
+// ────────────────────────────────────────────────────────────────────────
+
+// PeekStore is implemented by stores that can report fixed- and
+// sliding-window usage without recording a request. RedisStore,
+// MemoryStore and ShardedStore implement it.
+type PeekStore interface {
+	PeekFixedWindow(ctx context.Context, identifier string, limit int, windowSeconds int) (*FixedWindowResult, error)
+	PeekSlidingWindow(ctx context.Context, identifier string, limit int, windowSeconds int) (*SlidingWindowResult, error)
+}
+
+// peekFixedWindowScript returns {counter value, milliseconds until reset}
+// without touching the key; a missing key reads as {0, -2}.
+var peekFixedWindowScript = redis.NewScript(`
+local count = tonumber(redis.call("GET", KEYS[1])) or 0
+return {count, redis.call("PTTL", KEYS[1])}
+`)
+
+// peekSlidingWindowScript returns {units inside the window, score of the
+// entry whose expiry frees the next slot} without touching the key.
+// ARGV: [1] now (µs), [2] window (µs), [3] limit.
+var peekSlidingWindowScript = redis.NewScript(`
+local key    = KEYS[1]
+local now    = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit  = tonumber(ARGV[3])
+
+local count = redis.call("ZCOUNT", key, "(" .. (now - window), "+inf")
+if count == 0 then
+    return {0, now - window}
+end
+
+-- Room left: the oldest entry frees up first. Full: enough entries must
+-- age out to make room for one more request.
+local k = 0
+if count >= limit then
+    k = math.min(count + 1 - limit, count) - 1
+end
+local entry = redis.call("ZRANGEBYSCORE", key, "(" .. (now - window), "+inf", "WITHSCORES", "LIMIT", k, 1)
+
+return {count, tonumber(entry[2])}
+`)
+
+// PeekFixedWindow reports identifier's fixed-window usage without
+// counting a request.
+func PeekFixedWindow(ctx context.Context, rdb RedisRunner, identifier string, limit int, windowSeconds int) (*FixedWindowResult, error) {
+	key := redisKey("rate:fixed:", identifier)
+
+	res, err := peekFixedWindowScript.Run(ctx, rdb, []string{key}).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("peek fixed window script error: %w", err)
+	}
+
+	return newPeekFixedWindowResult(res[0], limit, windowSeconds, time.Duration(max(0, res[1]))*time.Millisecond), nil
+}
+
+// PeekSlidingWindow reports identifier's sliding-window usage without
+// recording a request or pruning old entries.
+func PeekSlidingWindow(ctx context.Context, rdb RedisRunner, identifier string, limit int, windowSeconds int) (*SlidingWindowResult, error) {
+	now := time.Now().UnixMicro()
+	windowUs := int64(windowSeconds) * 1e6
+	key := redisKey("rate:", identifier)
+
+	res, err := peekSlidingWindowScript.Run(ctx, rdb, []string{key}, now, windowUs, limit).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("peek sliding window script error: %w", err)
+	}
+
+	count, oldest := res[0], res[1]
+	return newSlidingWindowResult(count < int64(limit), count, limit, windowSeconds, now, max(now, oldest+windowUs)), nil
+}
+
+// newPeekFixedWindowResult is newFixedWindowResult for a request that was
+// not counted: it would be allowed while count is below limit.
+func newPeekFixedWindowResult(count int64, limit int, windowSeconds int, ttl time.Duration) *FixedWindowResult {
+	result := newFixedWindowResult(count, limit, windowSeconds, ttl)
+	result.Allowed = count < int64(limit)
+	if !result.Allowed {
+		result.RetryAfter = ttl
+	}
+	return result
+}
+
+// PeekFixedWindow runs PeekFixedWindow against the store's Redis.
+func (s *RedisStore) PeekFixedWindow(ctx context.Context, identifier string, limit int, windowSeconds int) (*FixedWindowResult, error) {
+	return PeekFixedWindow(ctx, s.rdb, identifier, limit, windowSeconds)
+}
+
+// PeekSlidingWindow runs PeekSlidingWindow against the store's Redis.
+func (s *RedisStore) PeekSlidingWindow(ctx context.Context, identifier string, limit int, windowSeconds int) (*SlidingWindowResult, error) {
+	return PeekSlidingWindow(ctx, s.rdb, identifier, limit, windowSeconds)
+}
+
+// PeekFixedWindow implements PeekStore on identifier's shard.
+func (s *ShardedStore) PeekFixedWindow(ctx context.Context, identifier string, limit int, windowSeconds int) (*FixedWindowResult, error) {
+	return s.shard(identifier).PeekFixedWindow(ctx, identifier, limit, windowSeconds)
+}
+
+// PeekSlidingWindow implements PeekStore on identifier's shard.
+func (s *ShardedStore) PeekSlidingWindow(ctx context.Context, identifier string, limit int, windowSeconds int) (*SlidingWindowResult, error) {
+	return s.shard(identifier).PeekSlidingWindow(ctx, identifier, limit, windowSeconds)
+}
+
+// peek runs fn on key's entry, or on nil when there is none, while holding
+// its shard's lock. Unlike with, it never creates an entry; fn may still
+// update an existing one.
+func (m *MemoryStore) peek(key string, fn func(e *memoryEntry)) {
+	sh := &m.shards[shardIndex(key)]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	fn(sh.entries[key])
+}
+
+// PeekFixedWindow mirrors PeekFixedWindow.
+func (m *MemoryStore) PeekFixedWindow(_ context.Context, identifier string, limit int, windowSeconds int) (*FixedWindowResult, error) {
+	var result *FixedWindowResult
+
+	m.peek(redisKey("rate:fixed:", identifier), func(e *memoryEntry) {
+		now := time.Now()
+		if e == nil || !now.Before(e.expires) {
+			result = newPeekFixedWindowResult(0, limit, windowSeconds, 0)
+			return
+		}
+		result = newPeekFixedWindowResult(e.count, limit, windowSeconds, e.expires.Sub(now))
+	})
+
+	return result, nil
+}
+
+// PeekSlidingWindow mirrors PeekSlidingWindow.
+func (m *MemoryStore) PeekSlidingWindow(_ context.Context, identifier string, limit int, windowSeconds int) (*SlidingWindowResult, error) {
+	var result *SlidingWindowResult
+
+	m.peek(redisKey("rate:", identifier), func(e *memoryEntry) {
+		now := time.Now().UnixMicro()
+		windowUs := int64(windowSeconds) * 1e6
+
+		var stamps []int64
+		if e != nil {
+			stamps = e.stamps
+		}
+		i := 0
+		for i < len(stamps) && stamps[i] <= now-windowUs {
+			i++
+		}
+		stamps = stamps[i:] // in-window entries, oldest first; not pruned
+
+		count := len(stamps)
+		resetUs := now
+		if count > 0 {
+			k := 0
+			if count >= limit {
+				k = min(count+1-limit, count) - 1
+			}
+			resetUs = stamps[k] + windowUs
+		}
+		result = newSlidingWindowResult(count < limit, int64(count), limit, windowSeconds, now, resetUs)
+	})
+
+	return result, nil
+}
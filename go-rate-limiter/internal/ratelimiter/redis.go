@@ -0,0 +1,59 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisRunner is the part of the go-redis API the limiter scripts need
+// (EVAL / EVALSHA / SCRIPT LOAD). *redis.Client, *redis.ClusterClient and
+// redis.UniversalClient all satisfy it, so every Check* function works
+// against a single node, Sentinel or Cluster deployment alike.
+type RedisRunner interface {
+	redis.Scripter
+}
+
+// redisKey builds the Redis key for identifier under prefix. The
+// identifier is wrapped in a {hash tag} so that every key belonging to one
+// client hashes to the same Redis Cluster slot.
+func redisKey(prefix, identifier string) string {
+	return prefix + "{" + identifier + "}"
+}
+
+// scripts lists every Lua script the package runs, for LoadScripts.
+var scripts = []*redis.Script{
+	fixedWindowScript,
+	slidingWindowScript,
+	slidingCounterScript,
+	tokenBucketScript,
+	leakyBucketScript,
+	inspectScript,
+	resetScript,
+	banScript,
+	bannedScript,
+	refundScript,
+	uniqueClientsScript,
+	calendarWindowScript,
+	peekFixedWindowScript,
+	peekSlidingWindowScript,
+	slidingLogScript,
+	reserveScript,
+}
+
+// LoadScripts caches every limiter script in Redis (SCRIPT LOAD), so the
+// first requests after startup are served by EVALSHA straight away.
+//
+// It is an optimisation, not a requirement: every check runs its script
+// via Script.Run, which falls back to a full EVAL — re-caching the script
+// — whenever Redis answers NOSCRIPT, e.g. after SCRIPT FLUSH, a restart,
+// or a failover to a replica with a cold script cache.
+func LoadScripts(ctx context.Context, rdb RedisRunner) error {
+	for _, s := range scripts {
+		if err := s.Load(ctx, rdb).Err(); err != nil {
+			return fmt.Errorf("load script: %w", err)
+		}
+	}
+	return nil
+}
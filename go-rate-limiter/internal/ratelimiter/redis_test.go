@@ -0,0 +1,74 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis starts an in-process Redis for the test and returns it with
+// a client connected to it. Both are closed when the test ends.
+func newTestRedis(t testing.TB) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return mr, rdb
+}
+
+// benchmarkCheck runs check in parallel, spreading the calls over 1024
+// clients, and reports throughput alongside the per-call latency.
+func benchmarkCheck(b *testing.B, check func(identifier string) error) {
+	var next atomic.Uint64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := check(fmt.Sprintf("client-%d", next.Add(1)%1024)); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "req/s")
+}
+
+// After SCRIPT FLUSH (or a failover to a replica with a cold script cache)
+// EVALSHA answers NOSCRIPT; checks must fall back to EVAL, not fail.
+func TestChecksSurviveScriptFlush(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	if err := LoadScripts(ctx, rdb); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.ScriptFlush(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := rdb.ScriptExists(ctx, fixedWindowScript.Hash(), slidingWindowScript.Hash()).Result(); err != nil || loaded[0] || loaded[1] {
+		t.Fatalf("setup: scripts still cached after SCRIPT FLUSH (%v, %v)", loaded, err)
+	}
+
+	if _, err := CheckFixedWindow(ctx, rdb, "client", 10, 60, 1); err != nil {
+		t.Fatalf("fixed window after SCRIPT FLUSH: %v", err)
+	}
+	if _, err := CheckSlidingWindow(ctx, rdb, "client", 10, 60, 1); err != nil {
+		t.Fatalf("sliding window after SCRIPT FLUSH: %v", err)
+	}
+
+	// The fallback EVAL caches the scripts again.
+	loaded, err := rdb.ScriptExists(ctx, fixedWindowScript.Hash(), slidingWindowScript.Hash()).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded[0] || !loaded[1] {
+		t.Fatalf("scripts not re-cached after the fallback: %v", loaded)
+	}
+}
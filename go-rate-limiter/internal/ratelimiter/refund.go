@@ -0,0 +1,134 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Refunds — Give Back Units Charged for a Failed or Abandoned Request
+// ────────────────────────────────────────────────────────────────────────
+//
+// Checks charge a request before it is proxied, since the upstream's
+// answer is not known yet. A refund undoes that charge afterwards:
+//
+//   fixed, calendar  DECRBY, never below zero; the window's TTL is kept
+//   sliding          ZREMRANGEBYRANK drops the newest cost members
+//   sliding_counter  "cur" in the hash, never below zero
+//   token_bucket     "tokens" back up, never above capacity
+//   leaky_bucket     "level" down, never below zero
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ RACES                                                              │
+// │                                                                    │
+// │  • Between the check and the refund the units count: concurrent   │
+// │    requests may be rejected that would have fitted had the failed │
+// │    one never been charged.                                         │
+// │  • Sliding-window members carry no request identity, so a refund  │
+// │    removes the newest ones — possibly another concurrent request's.│
+// │    The count is right; the timestamps freed may be a little off.   │
+// │  • A refund landing after the window rolled over comes off the     │
+// │    new window (fixed: skipped if the key expired meanwhile).       │
+// └────────────────────────────────────────────────────────────────────┘
+// ────────────────────────────────────────────────────────────────────────
+
+// RefundStore is implemented by stores that can give back units charged
+// by an earlier check. RedisStore and MemoryStore both implement it.
+type RefundStore interface {
+	// Refund returns cost units to identifier's state for mode. capacity
+	// is the bucket size (token_bucket) and ignored by other modes. A
+	// missing key — e.g. the window already expired — is left alone.
+	Refund(ctx context.Context, mode, identifier string, cost, capacity int) error
+}
+
+// refundScript undoes a check's charge on KEYS[1].
+// ARGV: [1] mode, [2] cost, [3] capacity (token_bucket).
+var refundScript = redis.NewScript(`
+local key = KEYS[1]
+local mode = ARGV[1]
+local cost = tonumber(ARGV[2])
+
+if redis.call("EXISTS", key) == 0 then
+  return 0
+end
+
+if mode == "fixed" or mode == "calendar" then
+  local count = redis.call("DECRBY", key, cost)
+  if count < 0 then
+    redis.call("INCRBY", key, -count)
+  end
+elseif mode == "sliding" then
+  redis.call("ZREMRANGEBYRANK", key, -cost, -1)
+elseif mode == "sliding_counter" then
+  local cur = tonumber(redis.call("HGET", key, "cur")) or 0
+  redis.call("HSET", key, "cur", math.max(0, cur - cost))
+elseif mode == "token_bucket" then
+  local tokens = tonumber(redis.call("HGET", key, "tokens")) or 0
+  redis.call("HSET", key, "tokens", math.min(tonumber(ARGV[3]), tokens + cost))
+elseif mode == "leaky_bucket" then
+  local level = tonumber(redis.call("HGET", key, "level")) or 0
+  redis.call("HSET", key, "level", math.max(0, level - cost))
+end
+return 1
+`)
+
+// statePrefix returns the key prefix mode stores its state under.
+func statePrefix(mode string) (string, error) {
+	for _, k := range stateKeys {
+		if k.mode == mode {
+			return k.prefix, nil
+		}
+	}
+	return "", fmt.Errorf("cannot refund unknown mode %q", mode)
+}
+
+// Refund implements RefundStore with a single atomic script.
+func (s *RedisStore) Refund(ctx context.Context, mode, identifier string, cost, capacity int) error {
+	prefix, err := statePrefix(mode)
+	if err != nil {
+		return err
+	}
+	key := redisKey(prefix, identifier)
+
+	if err := refundScript.Run(ctx, s.rdb, []string{key}, mode, cost, capacity).Err(); err != nil {
+		return fmt.Errorf("refund script error: %w", err)
+	}
+	return nil
+}
+
+// Refund implements RefundStore, mirroring the Redis script.
+func (m *MemoryStore) Refund(_ context.Context, mode, identifier string, cost, capacity int) error {
+	prefix, err := statePrefix(mode)
+	if err != nil {
+		return err
+	}
+
+	// A client without state has nothing to refund; peek, unlike with,
+	// does not create an entry for it.
+	m.peek(redisKey(prefix, identifier), func(e *memoryEntry) {
+		if e == nil {
+			return
+		}
+		switch mode {
+		case "fixed", "calendar":
+			if time.Now().Before(e.expires) {
+				e.count = max(0, e.count-int64(cost))
+			}
+		case "sliding":
+			e.stamps = e.stamps[:max(0, len(e.stamps)-cost)]
+		case "sliding_counter":
+			e.curCount = max(0, e.curCount-int64(cost))
+		case "token_bucket":
+			if !e.last.IsZero() {
+				e.level = math.Min(float64(capacity), e.level+float64(cost))
+			}
+		case "leaky_bucket":
+			e.level = math.Max(0, e.level-float64(cost))
+		}
+	})
+	return nil
+}
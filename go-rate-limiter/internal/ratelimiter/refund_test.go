@@ -0,0 +1,46 @@
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryRefundMissingKey(t *testing.T) {
+	m := NewMemoryStore()
+	defer m.Close()
+
+	for _, mode := range []string{"fixed", "sliding", "sliding_counter", "token_bucket", "leaky_bucket"} {
+		if err := m.Refund(context.Background(), mode, "client", 1, 10); err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+	}
+
+	for i := range m.shards {
+		if n := len(m.shards[i].entries); n != 0 {
+			t.Fatalf("refunds for an unknown client created %d entries", n)
+		}
+	}
+}
+
+func TestMemoryRefund(t *testing.T) {
+	m := NewMemoryStore()
+	defer m.Close()
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := m.FixedWindow(ctx, "client", 3, 60, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Refund(ctx, "fixed", "client", 1, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := m.FixedWindow(ctx, "client", 3, 60, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed || res.Count != 3 {
+		t.Fatalf("after a refund: allowed=%v count=%d, want allowed with count 3", res.Allowed, res.Count)
+	}
+}
@@ -0,0 +1,177 @@
+package ratelimiter
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Sharded Store — Spread Keys Over Independent Redis Instances
+// ────────────────────────────────────────────────────────────────────────
+//
+// One Redis runs every script on a single thread, which caps the checks
+// per second one deployment can make. ShardedStore spreads identifiers
+// over several independent Redis instances (no cluster needed): each
+// identifier is hashed onto a ring and its state lives — and its scripts
+// run — on the shard that owns that point.
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ CONSISTENT HASHING                                                 │
+// │                                                                    │
+// │  • Every shard owns ringReplicas points on the ring, placed by    │
+// │    hashing its name, so load evens out across shards.              │
+// │  • An identifier belongs to the first shard point at or after its │
+// │    own hash. Adding or removing a shard only moves the keys next  │
+// │    to its points — about 1/n of them — and those clients simply   │
+// │    start a fresh window on their new shard.                        │
+// │  • Shards are placed by name (e.g. "host1:6379"), not by position, │
+// │    so listing them in a different order changes nothing.           │
+// └────────────────────────────────────────────────────────────────────┘
+//
+// Everything for one identifier stays on one shard, so every check is
+// as atomic as on a single Redis. A shard that is down only affects the
+// clients it owns.
+// ────────────────────────────────────────────────────────────────────────
+
+// ringReplicas is the number of ring points per shard.
+const ringReplicas = 160
+
+// ringPoint is one point on the hash ring and the shard that owns it.
+type ringPoint struct {
+	hash  uint64
+	shard *RedisStore
+}
+
+// ShardedStore is a Store that spreads identifiers over several
+// RedisStores by consistent hashing.
+type ShardedStore struct {
+	shards []*RedisStore
+	ring   []ringPoint // sorted by hash
+}
+
+// NewShardedStore returns a Store spreading identifiers over shards,
+// keyed by a stable name for each — usually its address. shards must not
+// be empty.
+func NewShardedStore(shards map[string]*RedisStore) *ShardedStore {
+	s := &ShardedStore{}
+	for name, shard := range shards {
+		s.shards = append(s.shards, shard)
+		for i := 0; i < ringReplicas; i++ {
+			s.ring = append(s.ring, ringPoint{hash: ringHash(name + "#" + strconv.Itoa(i)), shard: shard})
+		}
+	}
+	sort.Slice(s.ring, func(i, j int) bool { return s.ring[i].hash < s.ring[j].hash })
+	return s
+}
+
+// ringHash places a key or shard point on the ring: 64-bit FNV-1a,
+// finished with MurmurHash3's fmix64. FNV alone spreads short, similar
+// strings ("host1:6379#0", "#1", …) poorly over the high bits.
+func ringHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// shard returns the shard that owns identifier.
+func (s *ShardedStore) shard(identifier string) *RedisStore {
+	h := ringHash(identifier)
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= h })
+	if i == len(s.ring) {
+		i = 0 // wrap around
+	}
+	return s.ring[i].shard
+}
+
+// FixedWindow runs the fixed-window check on identifier's shard.
+func (s *ShardedStore) FixedWindow(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*FixedWindowResult, error) {
+	return s.shard(identifier).FixedWindow(ctx, identifier, limit, windowSeconds, cost)
+}
+
+// SlidingWindow runs the sliding-window check on identifier's shard.
+func (s *ShardedStore) SlidingWindow(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*SlidingWindowResult, error) {
+	return s.shard(identifier).SlidingWindow(ctx, identifier, limit, windowSeconds, cost)
+}
+
+// SlidingCounter runs the sliding-counter check on identifier's shard.
+func (s *ShardedStore) SlidingCounter(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*SlidingCounterResult, error) {
+	return s.shard(identifier).SlidingCounter(ctx, identifier, limit, windowSeconds, cost)
+}
+
+// TokenBucket runs the token-bucket check on identifier's shard.
+func (s *ShardedStore) TokenBucket(ctx context.Context, identifier string, capacity int, refillPerSec float64, cost int) (*TokenBucketResult, error) {
+	return s.shard(identifier).TokenBucket(ctx, identifier, capacity, refillPerSec, cost)
+}
+
+// LeakyBucket runs the leaky-bucket check on identifier's shard.
+func (s *ShardedStore) LeakyBucket(ctx context.Context, identifier string, capacity int, leakRatePerSec float64, cost int) (*LeakyBucketResult, error) {
+	return s.shard(identifier).LeakyBucket(ctx, identifier, capacity, leakRatePerSec, cost)
+}
+
+// CalendarWindow runs the calendar-window check on identifier's shard.
+func (s *ShardedStore) CalendarWindow(ctx context.Context, identifier string, limit int, period string, cost int) (*FixedWindowResult, error) {
+	return s.shard(identifier).CalendarWindow(ctx, identifier, limit, period, cost)
+}
+
+// Inspect implements AdminStore on identifier's shard.
+func (s *ShardedStore) Inspect(ctx context.Context, identifier string) ([]KeyState, error) {
+	return s.shard(identifier).Inspect(ctx, identifier)
+}
+
+// Reset implements AdminStore on identifier's shard.
+func (s *ShardedStore) Reset(ctx context.Context, identifier string) error {
+	return s.shard(identifier).Reset(ctx, identifier)
+}
+
+// RecordBreach implements BreachStore on identifier's shard.
+func (s *ShardedStore) RecordBreach(ctx context.Context, identifier string, windowSeconds int) (int64, error) {
+	return s.shard(identifier).RecordBreach(ctx, identifier, windowSeconds)
+}
+
+// Ban implements BanStore on identifier's shard.
+func (s *ShardedStore) Ban(ctx context.Context, identifier string, d time.Duration) error {
+	return s.shard(identifier).Ban(ctx, identifier, d)
+}
+
+// Banned implements BanStore on identifier's shard.
+func (s *ShardedStore) Banned(ctx context.Context, identifier string) (time.Duration, error) {
+	return s.shard(identifier).Banned(ctx, identifier)
+}
+
+// Refund implements RefundStore on identifier's shard.
+func (s *ShardedStore) Refund(ctx context.Context, mode, identifier string, cost, capacity int) error {
+	return s.shard(identifier).Refund(ctx, mode, identifier, cost, capacity)
+}
+
+// UniqueClients implements UniqueClientStore on the shard owning scope,
+// so each scope is counted in one place.
+func (s *ShardedStore) UniqueClients(ctx context.Context, scope, identifier string, limit, windowSeconds int) (*UniqueClientsResult, error) {
+	return s.shard(scope).UniqueClients(ctx, scope, identifier, limit, windowSeconds)
+}
+
+// Stats implements StatsStore by merging every shard's stats. Each shard
+// reads at most maxStatsKeys keys.
+func (s *ShardedStore) Stats(ctx context.Context, n int) (*Stats, error) {
+	merged := &Stats{Top: map[string][]IdentifierCount{}}
+	for _, shard := range s.shards {
+		stats, err := shard.Stats(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		merged.Scanned += stats.Scanned
+		merged.Truncated = merged.Truncated || stats.Truncated
+		for mode, ids := range stats.Top {
+			merged.Top[mode] = append(merged.Top[mode], ids...)
+		}
+	}
+	return rankStats(merged, n), nil
+}
@@ -0,0 +1,178 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Sliding-Window Counter — Approximate, O(1) Memory, Atomic Lua Script
+// ────────────────────────────────────────────────────────────────────────
+//
+// Algorithm (Redis Hash: win, cur, prev):
+//   1. HMGET   → current window start and the two window counters
+//   2. Roll    → if time has moved into the next window, cur becomes prev;
+//                if a whole window was skipped, both reset to zero
+//   3. Blend   → estimate = prev × (1 − elapsed fraction) + cur
+//   4. Admit   → if estimate + cost ≤ limit, add cost to cur
+//   5. HSET + PEXPIRE → persist, keep the key for two windows
+//
+//        previous window          current window
+//   ├────────────────────────┼────────────────────────┤
+//             ├──────── sliding window ────────┤
+//             ▲ weight (1 − f) of prev         ▲ now (fraction f in)
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ WHY O(1) MEMORY?                                                   │
+// │                                                                    │
+// │  • Three integers per client, whatever the limit — unlike the     │
+// │    sliding-window ZSET, which stores one member per request.      │
+// │  • The estimate assumes the previous window's requests were       │
+// │    spread evenly; in practice the error is small, and far below   │
+// │    the 2× boundary burst a plain fixed window allows.              │
+// └────────────────────────────────────────────────────────────────────┘
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ ZERO RACE CONDITIONS                                               │
+// │                                                                    │
+// │  • Roll-blend-admit-write runs inside a single Lua script, so two │
+// │    concurrent requests can never both take the last slot.         │
+// │  • Rejected requests are not counted, so a client that keeps      │
+// │    retrying while blocked is not locked out any longer.           │
+// └────────────────────────────────────────────────────────────────────┘
+// ────────────────────────────────────────────────────────────────────────
+
+// slidingCounterScript rolls, blends and admits against a two-window
+// counter stored in a Redis hash. Returns {allowed (0|1), cur, prev,
+// current window start in ms}; the caller computes the estimate.
+//
+// Time complexity per call: O(1)
+// Race conditions:          None (atomic Lua script)
+var slidingCounterScript = redis.NewScript(`
+local key          = KEYS[1]
+local limit        = tonumber(ARGV[1])
+local window       = tonumber(ARGV[2])   -- milliseconds
+local now          = tonumber(ARGV[3])   -- milliseconds
+local cost         = tonumber(ARGV[4])
+
+-- 1. Load both counters; a missing key is two empty windows  — O(1)
+local state = redis.call("HMGET", key, "win", "cur", "prev")
+local win   = tonumber(state[1])
+local cur   = tonumber(state[2]) or 0
+local prev  = tonumber(state[3]) or 0
+
+-- 2. Roll the windows forward to the one containing now      — O(1)
+local start = now - (now % window)
+if win ~= start then
+    if win == start - window then
+        prev = cur
+    else
+        prev = 0
+    end
+    cur = 0
+end
+
+-- 3. Weight the previous window by its remaining overlap     — O(1)
+local weight   = 1 - (now - start) / window
+local estimate = prev * weight + cur
+
+-- 4. Admit the request if its cost still fits                — O(1)
+local allowed = 0
+if estimate + cost <= limit then
+    cur     = cur + cost
+    allowed = 1
+end
+
+-- 5. Persist state; it matters for two windows at most       — O(1)
+redis.call("HSET", key, "win", start, "cur", cur, "prev", prev)
+redis.call("PEXPIRE", key, window * 2)
+
+return {allowed, cur, prev, start}
+`)
+
+// SlidingCounterResult holds the outcome of a sliding-window-counter
+// rate-limit check.
+type SlidingCounterResult struct {
+	Allowed    bool          // whether the request should be forwarded
+	Count      int64         // estimated units inside the sliding window (rounded up)
+	Limit      int           // configured maximum requests per window
+	WindowSec  int           // window duration in seconds
+	Reset      time.Time     // when the current fixed window ends
+	RetryAfter time.Duration // estimated time until the request would fit; zero when allowed
+}
+
+// CheckSlidingCounter performs an approximate sliding-window rate-limit
+// check for the given identifier, blending the current and previous
+// fixed-window counts. The request consumes cost units of the limit.
+//
+// Guarantees:
+//   - O(1) time and memory: three hash fields per identifier.
+//   - Zero race conditions: all operations run in a single atomic Lua script.
+//   - Rejected requests are not counted against the client.
+func CheckSlidingCounter(ctx context.Context, rdb RedisRunner, identifier string, limit int, windowSeconds int, cost int) (*SlidingCounterResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()           // millisecond precision
+	windowMs := int64(windowSeconds) * 1000 // window in ms
+
+	key := redisKey("rate:counter:", identifier)
+
+	res, err := slidingCounterScript.Run(ctx, rdb, []string{key},
+		limit,    // ARGV[1]
+		windowMs, // ARGV[2]
+		now,      // ARGV[3]
+		cost,     // ARGV[4]
+	).Int64Slice()
+
+	if err != nil {
+		return nil, fmt.Errorf("sliding counter script error: %w", err)
+	}
+
+	allowed, cur, prev, start := res[0] == 1, res[1], res[2], res[3]
+
+	return newSlidingCounterResult(allowed, cur, prev, start, now, limit, windowSeconds, cost), nil
+}
+
+// newSlidingCounterResult builds the result for a window starting at
+// startMs holding cur units, after a previous window of prev units.
+func newSlidingCounterResult(allowed bool, cur, prev, startMs, nowMs int64, limit int, windowSeconds int, cost int) *SlidingCounterResult {
+	windowMs := int64(windowSeconds) * 1000
+	elapsed := float64(nowMs-startMs) / float64(windowMs)
+	estimate := float64(prev)*(1-elapsed) + float64(cur)
+
+	result := &SlidingCounterResult{
+		Allowed:   allowed,
+		Count:     int64(math.Ceil(estimate)),
+		Limit:     limit,
+		WindowSec: windowSeconds,
+		Reset:     time.UnixMilli(startMs + windowMs),
+	}
+	if !allowed {
+		result.RetryAfter = slidingCounterWait(cur, prev, startMs, nowMs, windowMs, limit, cost)
+	}
+
+	return result
+}
+
+// slidingCounterWait estimates how long until a request of cost fits:
+// while cur alone leaves room, the previous window's weight only has to
+// decay far enough; otherwise the client must wait for the next window.
+func slidingCounterWait(cur, prev, startMs, nowMs, windowMs int64, limit int, cost int) time.Duration {
+	windowEnd := startMs + windowMs
+	room := int64(limit) - cur - int64(cost)
+
+	at := windowEnd
+	if room >= 0 && prev > 0 {
+		// prev × (1 − f) ≤ room  ⇔  f ≥ 1 − room / prev
+		f := 1 - float64(room)/float64(prev)
+		at = startMs + int64(math.Ceil(f*float64(windowMs)))
+	}
+
+	return time.Duration(max(0, at-nowMs)) * time.Millisecond
+}
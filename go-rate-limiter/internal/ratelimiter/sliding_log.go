@@ -0,0 +1,79 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Sliding Log — Every Request a Client Has in Its Sliding Window
+// ────────────────────────────────────────────────────────────────────────
+//
+// The sliding window already stores one timestamp per request (one per
+// unit of cost), so the exact request pattern of a suspicious client is
+// one ZRANGE away. Like Inspect, the log is raw: entries that have aged
+// out but were not yet pruned by the client's next check are included.
+// ────────────────────────────────────────────────────────────────────────
+
+// SlidingLogStore is implemented by stores that can list the timestamps
+// in an identifier's sliding window. RedisStore, MemoryStore and
+// ShardedStore implement it.
+type SlidingLogStore interface {
+	// SlidingLog returns the recorded timestamps, oldest first; an
+	// identifier without sliding-window state returns none.
+	SlidingLog(ctx context.Context, identifier string) ([]time.Time, error)
+}
+
+// slidingLogScript returns the score (timestamp in µs) of every member of
+// KEYS[1], oldest first.
+//
+// Time complexity per call: O(N) for N ≤ limit entries
+var slidingLogScript = redis.NewScript(`
+local entries = redis.call("ZRANGE", KEYS[1], 0, -1, "WITHSCORES")
+local out = {}
+
+for i = 2, #entries, 2 do
+    out[#out + 1] = tonumber(entries[i])
+end
+
+return out
+`)
+
+// SlidingLog implements SlidingLogStore with ZRANGE 0 -1 WITHSCORES.
+func (s *RedisStore) SlidingLog(ctx context.Context, identifier string) ([]time.Time, error) {
+	res, err := slidingLogScript.Run(ctx, s.rdb, []string{redisKey("rate:", identifier)}).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("sliding log script error: %w", err)
+	}
+
+	stamps := make([]time.Time, len(res))
+	for i, us := range res {
+		stamps[i] = time.UnixMicro(us)
+	}
+	return stamps, nil
+}
+
+// SlidingLog implements SlidingLogStore on identifier's shard.
+func (s *ShardedStore) SlidingLog(ctx context.Context, identifier string) ([]time.Time, error) {
+	return s.shard(identifier).SlidingLog(ctx, identifier)
+}
+
+// SlidingLog implements SlidingLogStore over the entry's timestamps.
+func (m *MemoryStore) SlidingLog(_ context.Context, identifier string) ([]time.Time, error) {
+	var stamps []time.Time
+
+	m.peek(redisKey("rate:", identifier), func(e *memoryEntry) {
+		if e == nil || !e.evictAt.After(time.Now()) {
+			return
+		}
+		stamps = make([]time.Time, len(e.stamps))
+		for i, us := range e.stamps {
+			stamps[i] = time.UnixMicro(us)
+		}
+	})
+
+	return stamps, nil
+}
@@ -2,7 +2,10 @@ package ratelimiter
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -13,23 +16,47 @@ import (
 // ────────────────────────────────────────────────────────────────────────
 //
 // Algorithm (Redis Sorted Set — ZSET):
-//   1. ZREMRANGEBYSCORE  → prune entries older than the window
-//   2. ZADD              → insert current timestamp as score + member
-//   3. ZCARD             → count entries remaining in the set
-//   4. EXPIRE            → refresh TTL to auto-clean the key
+//   1. ZREMRANGEBYSCORE  → prune entries that have aged out of the window
+//   2. ZREMRANGEBYRANK   → safeguard: trim to the newest `limit` entries
+//   3. ZCARD             → count entries still inside the window
+//   4. ZADD              → only if the request fits: insert one member per
+//                          unit of cost, scored with the current timestamp
+//                          in microseconds
+//   5. EXPIRE            → refresh TTL to auto-clean the key
+//   6. ZRANGE k k        → the entry whose expiry frees enough room, to
+//                          report when the window frees up
+//
+// Scores are microseconds, not milliseconds: at thousands of requests per
+// second a millisecond holds several requests, all sharing one score, so
+// they would age out — and free their slots — together, in coarse steps.
+// A microsecond epoch (~1.8e15) is still exact in the doubles Lua and ZSET
+// scores use (up to 2^53). Entries scored in milliseconds by an older
+// version look decades old and are pruned on the key's next check, so an
+// upgrade starts those clients on a fresh window; roll it out to every
+// instance sharing the Redis at once.
+//
+// Window boundary: the window is the half-open interval (now − window, now].
+// An entry exactly `window` µs old has aged out — the same instant the
+// Reset / Retry-After values point at, so a client that waits exactly that
+// long is admitted.
 //
 // ┌────────────────────────────────────────────────────────────────────┐
 // │ TIME COMPLEXITY                                                    │
 // │                                                                    │
 // │  Per-request cost:                                                 │
 // │    ZREMRANGEBYSCORE  O(log N + M)  N = set size, M = removed      │
-// │    ZADD              O(log N)                                      │
+// │    ZREMRANGEBYRANK   O(log N + M)  M = 0 unless over the cap      │
 // │    ZCARD             O(1)                                          │
+// │    ZADD              O(cost × log N)                               │
 // │    EXPIRE            O(1)                                          │
+// │    ZRANGE k k        O(log N)                                      │
 // │                                                                    │
 // │  N is bounded by `limit` (e.g. 100), so in practice the cost is   │
-// │  effectively constant for any configured rate limit. The set       │
-// │  never grows beyond limit+1 entries before the next cleanup.       │
+// │  effectively constant for any configured rate limit. Rejected      │
+// │  requests are never added, so the set never exceeds `limit`.       │
+// │  Step 2 enforces that bound regardless, so a runaway set (a huge  │
+// │  limit lowered at runtime, or a bug) is cut back on its next       │
+// │  check instead of growing Redis memory.                            │
 // │                                                                    │
 // │  → Amortised O(1) for bounded limits.                             │
 // └────────────────────────────────────────────────────────────────────┘
@@ -37,7 +64,7 @@ import (
 // ┌────────────────────────────────────────────────────────────────────┐
 // │ ZERO RACE CONDITIONS                                               │
 // │                                                                    │
-// │  • All four steps execute inside a single Lua script.              │
+// │  • All six steps execute inside a single Lua script.               │
 // │  • Redis is single-threaded and runs each Lua script atomically — │
 // │    no other command can interleave.                                 │
 // │  • Even under 1000 concurrent requests, each invocation sees a    │
@@ -53,6 +80,13 @@ import (
 // slidingWindowScript is an atomic Lua script that implements the
 // sliding-window rate limiting algorithm using a Redis Sorted Set.
 //
+// Returns {allowed (0|1), count, reset timestamp in µs}. count includes
+// this request only when it was allowed — a rejected request is never
+// recorded, so a flood of rejections cannot keep the window full. reset
+// is when the window frees up: for an allowed request, when the oldest
+// entry ages out; for a rejected one, when enough entries have aged out
+// for it to fit.
+//
 // Atomicity guarantee: Redis executes the entire script without
 // interleaving other commands, eliminating all race conditions.
 var slidingWindowScript = redis.NewScript(`
@@ -61,61 +95,129 @@ local now          = tonumber(ARGV[1])
 local window       = tonumber(ARGV[2])
 local expire_sec   = tonumber(ARGV[3])
 local member       = ARGV[4]
+local cost         = tonumber(ARGV[5])
+local limit        = tonumber(ARGV[6])
 
--- 1. Remove timestamps older than the window  — O(log N + M)
+-- 1. Remove entries that have aged out       — O(log N + M)
 redis.call("ZREMRANGEBYSCORE", key, 0, now - window)
 
--- 2. Add this request's timestamp             — O(log N)
-redis.call("ZADD", key, now, member)
+-- 2. Cap the set at the newest limit entries  — O(log N + M)
+redis.call("ZREMRANGEBYRANK", key, 0, -(limit + 1))
 
 -- 3. Count requests inside the window         — O(1)
 local count = redis.call("ZCARD", key)
 
--- 4. Refresh TTL so the key self-cleans       — O(1)
+-- 4. Record the request only if it fits       — O(cost × log N)
+local allowed = 0
+if count + cost <= limit then
+    for i = 1, cost do
+        redis.call("ZADD", key, now, member .. ":" .. i)
+    end
+    count   = count + cost
+    allowed = 1
+end
+
+-- 5. Refresh TTL so the key self-cleans       — O(1)
 redis.call("EXPIRE", key, expire_sec)
 
-return count
+-- 6. When the window frees up                 — O(log N)
+if count == 0 then
+    return {allowed, count, now}
+end
+local k = 0
+if allowed == 0 then
+    k = math.min(count + cost - limit, count) - 1
+end
+local entry = redis.call("ZRANGE", key, k, k, "WITHSCORES")
+
+return {allowed, count, tonumber(entry[2]) + window}
 `)
 
 // SlidingWindowResult holds the outcome of a sliding-window rate-limit check.
 type SlidingWindowResult struct {
-	Allowed   bool  // whether the request should be forwarded
-	Count     int64 // current request count inside the window
-	Limit     int   // configured maximum requests per window
-	WindowSec int   // window duration in seconds
+	Allowed    bool          // whether the request should be forwarded
+	Count      int64         // units consumed inside the window, including this request if allowed
+	Limit      int           // configured maximum requests per window
+	WindowSec  int           // window duration in seconds
+	Reset      time.Time     // when the window frees up (see slidingWindowScript)
+	RetryAfter time.Duration // time until a slot frees up; zero when allowed
 }
 
 // CheckSlidingWindow performs a sliding-window rate-limit check for the
-// given identifier (e.g. an IP address).  It returns whether the request
-// is allowed and the current request count inside the window.
+// given identifier (e.g. an IP address).  The request consumes cost units
+// of the limit (1 for a plain count). It returns whether the request is
+// allowed and the units consumed inside the window.
 //
 // Guarantees:
 //   - Zero race conditions: all operations run in a single atomic Lua script.
-//   - Amortised O(1) for bounded limits: ZSET size never exceeds limit+1.
+//   - Amortised O(1) for bounded limits: ZSET size never exceeds limit.
+//   - Rejected requests are not recorded and never extend the window.
 //   - Safe across multiple GoShield instances sharing the same Redis.
-func CheckSlidingWindow(ctx context.Context, rdb *redis.Client, identifier string, limit int, windowSeconds int) (*SlidingWindowResult, error) {
-	now := time.Now().UnixMilli()                                  // millisecond precision
-	windowMs := int64(windowSeconds) * 1000                        // window in ms
-	expireSec := int64(windowSeconds) + 1                          // TTL slightly above window
-	member := fmt.Sprintf("%d:%d", now, time.Now().UnixNano())     // unique member per request
+func CheckSlidingWindow(ctx context.Context, rdb RedisRunner, identifier string, limit int, windowSeconds int, cost int) (*SlidingWindowResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMicro()          // microsecond precision
+	windowUs := int64(windowSeconds) * 1e6 // window in µs
+	expireSec := int64(windowSeconds) + 1  // TTL slightly above window
+	member := newSlidingMember(now)        // unique member per request
 
-	key := "rate:" + identifier
+	key := redisKey("rate:", identifier)
 
-	count, err := slidingWindowScript.Run(ctx, rdb, []string{key},
+	res, err := slidingWindowScript.Run(ctx, rdb, []string{key},
 		now,       // ARGV[1]
-		windowMs,  // ARGV[2]
+		windowUs,  // ARGV[2]
 		expireSec, // ARGV[3]
 		member,    // ARGV[4]
-	).Int64()
+		cost,      // ARGV[5]
+		limit,     // ARGV[6]
+	).Int64Slice()
 
 	if err != nil {
 		return nil, fmt.Errorf("sliding window script error: %w", err)
 	}
 
-	return &SlidingWindowResult{
-		Allowed:   count <= int64(limit),
+	allowed, count, resetUs := res[0] == 1, res[1], res[2]
+
+	return newSlidingWindowResult(allowed, count, limit, windowSeconds, now, resetUs), nil
+}
+
+// memberNonce and memberSeq make sliding-window members unique: a random
+// per-process prefix keeps instances sharing a key apart, and the counter
+// keeps calls within one process apart, however coarse the clock. A
+// duplicate member would make ZADD update an entry instead of adding one,
+// undercounting the window.
+var (
+	memberNonce = newMemberNonce()
+	memberSeq   atomic.Uint64
+)
+
+func newMemberNonce() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newSlidingMember returns a ZSET member for a request at nowUs that no
+// other request, on this or any other instance, will use.
+func newSlidingMember(nowUs int64) string {
+	return fmt.Sprintf("%d:%s:%d", nowUs, memberNonce, memberSeq.Add(1))
+}
+
+// newSlidingWindowResult builds the result for a window holding count
+// units at nowUs that frees up at resetUs (Unix microseconds).
+func newSlidingWindowResult(allowed bool, count int64, limit int, windowSeconds int, nowUs, resetUs int64) *SlidingWindowResult {
+	result := &SlidingWindowResult{
+		Allowed:   allowed,
 		Count:     count,
 		Limit:     limit,
 		WindowSec: windowSeconds,
-	}, nil
+		Reset:     time.UnixMicro(resetUs),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration(resetUs-nowUs) * time.Microsecond // enough entries age out
+	}
+
+	return result
 }
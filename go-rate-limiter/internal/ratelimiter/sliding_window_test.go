@@ -0,0 +1,292 @@
+package ratelimiter
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// runSlidingScript runs slidingWindowScript for identifier at nowUs, so a
+// test controls the clock, and returns whether the request was allowed and
+// the count it reported.
+func runSlidingScript(t *testing.T, rdb *redis.Client, identifier string, nowUs int64, limit, windowSeconds, cost int) (bool, int64) {
+	t.Helper()
+
+	res, err := slidingWindowScript.Run(context.Background(), rdb, []string{redisKey("rate:", identifier)},
+		nowUs, int64(windowSeconds)*1e6, windowSeconds+1, newSlidingMember(nowUs), cost, limit,
+	).Int64Slice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res[0] == 1, res[1]
+}
+
+func TestCheckSlidingWindow(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+	const limit, window = 3, 1
+
+	for i := 1; i <= limit; i++ {
+		res, err := CheckSlidingWindow(ctx, rdb, "client", limit, window, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Count != int64(i) {
+			t.Fatalf("request %d: allowed=%v count=%d, want allowed with count %d", i, res.Allowed, res.Count, i)
+		}
+		if i == 1 {
+			if ttl := mr.TTL("rate:{client}"); ttl != (window+1)*time.Second {
+				t.Fatalf("first hit set TTL %v, want %v", ttl, (window+1)*time.Second)
+			}
+		}
+	}
+
+	res, err := CheckSlidingWindow(ctx, rdb, "client", limit, window, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed {
+		t.Fatalf("request %d allowed, want blocked", limit+1)
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > window*time.Second {
+		t.Fatalf("RetryAfter = %v, want within (0, %ds]", res.RetryAfter, window)
+	}
+
+	// The scores are wall-clock timestamps, so the window has to pass for real.
+	time.Sleep(res.RetryAfter + 10*time.Millisecond)
+
+	res, err = CheckSlidingWindow(ctx, rdb, "client", limit, window, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed {
+		t.Fatal("request after the window blocked, want allowed")
+	}
+}
+
+// Rejected requests must not be recorded: if they were, a client flooding
+// past its limit would keep its own window full and never recover.
+func TestSlidingWindowRejectedRequestsDoNotExtendWindow(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	const limit, window, sent = 100, 1, 150
+	const step = int64(window) * 1e6 / sent
+
+	t0 := time.Now().UnixMicro()
+	allowed := 0
+	for i := range int64(sent) {
+		if ok, _ := runSlidingScript(t, rdb, "client", t0+i*step, limit, window, 1); ok {
+			allowed++
+		}
+	}
+	if allowed != limit {
+		t.Fatalf("%d of %d requests allowed, want %d", allowed, sent, limit)
+	}
+
+	// Once the last allowed request has aged out the window is empty again,
+	// whatever was rejected after it.
+	ok, count := runSlidingScript(t, rdb, "client", t0+(limit-1)*step+window*1e6, limit, window, 1)
+	if !ok || count != 1 {
+		t.Fatalf("after the window: allowed=%v count=%d, want allowed into an empty window", ok, count)
+	}
+}
+
+func TestMemorySlidingWindowRejectedRequestsDoNotExtendWindow(t *testing.T) {
+	m := NewMemoryStore()
+	defer m.Close()
+	ctx := context.Background()
+	const limit, window = 100, 1
+
+	for i := range limit {
+		res, err := m.SlidingWindow(ctx, "client", limit, window, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed {
+			t.Fatalf("request %d blocked, want allowed", i+1)
+		}
+	}
+
+	time.Sleep(window * time.Second / 2)
+	for i := range 50 {
+		res, err := m.SlidingWindow(ctx, "client", limit, window, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed {
+			t.Fatalf("request %d allowed, want blocked", limit+i+1)
+		}
+	}
+
+	// The allowed requests have aged out; the rejected ones, half a window
+	// younger, must not have been recorded.
+	time.Sleep(window*time.Second/2 + 100*time.Millisecond)
+	res, err := m.SlidingWindow(ctx, "client", limit, window, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed || res.Count != 1 {
+		t.Fatalf("after the window: allowed=%v count=%d, want allowed into an empty window", res.Allowed, res.Count)
+	}
+}
+
+// An entry counts while it is younger than the window: at exactly window
+// old it is pruned. A rejected request is never recorded.
+func TestSlidingWindowBoundary(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	const limit, window = 2, 1
+	const windowUs = int64(window) * 1e6
+	const key = "rate:{client}"
+
+	t0 := time.Now().UnixMicro()
+	for i, at := range []int64{t0, t0 + 1} {
+		if ok, _ := runSlidingScript(t, rdb, "client", at, limit, window, 1); !ok {
+			t.Fatalf("request %d blocked, want allowed", i+1)
+		}
+	}
+
+	// One µs before the first entry ages out the window is still full.
+	if ok, count := runSlidingScript(t, rdb, "client", t0+windowUs-1, limit, window, 1); ok || count != limit {
+		t.Fatalf("just inside the window: allowed=%v count=%d, want blocked at %d", ok, count, limit)
+	}
+	if members, _ := mr.ZMembers(key); len(members) != limit {
+		t.Fatalf("ZSET holds %d members after a rejection, want %d", len(members), limit)
+	}
+
+	// Exactly one window later the first entry is gone.
+	ok, count := runSlidingScript(t, rdb, "client", t0+windowUs, limit, window, 1)
+	if !ok || count != limit {
+		t.Fatalf("at the boundary: allowed=%v count=%d, want allowed with count %d", ok, count, limit)
+	}
+	if members, _ := mr.ZMembers(key); len(members) != limit {
+		t.Fatalf("ZSET holds %d members, want %d", len(members), limit)
+	}
+}
+
+func TestMemorySlidingWindowDoesNotRecordRejections(t *testing.T) {
+	m := NewMemoryStore()
+	defer m.Close()
+	ctx := context.Background()
+	const limit = 3
+
+	for range limit + 5 {
+		if _, err := m.SlidingWindow(ctx, "client", limit, 60, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stamps, err := m.SlidingLog(ctx, "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stamps) != limit {
+		t.Fatalf("%d timestamps recorded, want %d", len(stamps), limit)
+	}
+}
+
+// The ZSET never holds more than limit entries, however many requests are
+// rejected, and one overfilled by an earlier, larger limit (or anything
+// else) is trimmed back on the next check.
+func TestSlidingWindowZSetBounded(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+	const key = "rate:{client}"
+	const limit = 10
+
+	// Fill the window under a limit ten times higher.
+	for range 100 {
+		if _, err := CheckSlidingWindow(ctx, rdb, "client", 100, 60, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if members, _ := mr.ZMembers(key); len(members) != 100 {
+		t.Fatalf("setup: ZSET holds %d members, want 100", len(members))
+	}
+
+	for i := range 1000 {
+		res, err := CheckSlidingWindow(ctx, rdb, "client", limit, 60, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed {
+			t.Fatalf("request %d allowed in a full window", i+1)
+		}
+		if members, _ := mr.ZMembers(key); len(members) > limit {
+			t.Fatalf("after request %d the ZSET holds %d members, want at most %d", i+1, len(members), limit)
+		}
+	}
+}
+
+// Members generated in the same microsecond, on any goroutine, must still
+// differ: ZADD with a duplicate member updates instead of inserting, which
+// would undercount.
+func TestNewSlidingMemberUnique(t *testing.T) {
+	const goroutines, perGoroutine = 8, 20000
+	now := time.Now().UnixMicro() // one timestamp for all, the worst case
+
+	members := make(chan []string, goroutines)
+	for range goroutines {
+		go func() {
+			batch := make([]string, perGoroutine)
+			for i := range batch {
+				batch[i] = newSlidingMember(now)
+			}
+			members <- batch
+		}()
+	}
+
+	seen := make(map[string]bool, goroutines*perGoroutine)
+	for range goroutines {
+		for _, m := range <-members {
+			if seen[m] {
+				t.Fatalf("duplicate member %q", m)
+			}
+			seen[m] = true
+		}
+	}
+}
+
+// At 1000 requests per second, offered at twice that rate with sub-ms
+// spacing, the window admits exactly the limit in every second and never
+// more than the limit in any trailing window.
+func TestSlidingWindowAccuracyAt1000PerSecond(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	const limit, window = 1000, 1
+	const windowUs = int64(window) * 1e6
+	const step, seconds = 500, 2 // µs between requests; 2000 req/s offered
+
+	t0 := time.Now().UnixMicro()
+	var admitted []int64
+	for at := t0; at < t0+seconds*windowUs; at += step {
+		if ok, _ := runSlidingScript(t, rdb, "client", at, limit, window, 1); ok {
+			admitted = append(admitted, at)
+		}
+	}
+
+	if len(admitted) != seconds*limit {
+		t.Fatalf("admitted %d requests in %ds, want %d", len(admitted), seconds, seconds*limit)
+	}
+	// admitted is sorted: the entries within (at-window, at] run from
+	// index oldest to i.
+	oldest := 0
+	for i, at := range admitted {
+		for admitted[oldest] <= at-windowUs {
+			oldest++
+		}
+		if n := i - oldest + 1; n > limit {
+			t.Fatalf("%d requests admitted in the window ending at +%dµs, want at most %d", n, at-t0, limit)
+		}
+	}
+}
+
+func BenchmarkSlidingWindow(b *testing.B) {
+	_, rdb := newTestRedis(b)
+	ctx := context.Background()
+
+	benchmarkCheck(b, func(identifier string) error {
+		_, err := CheckSlidingWindow(ctx, rdb, identifier, math.MaxInt32, 60, 1)
+		return err
+	})
+}
@@ -0,0 +1,203 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Stats — Who Is Using the Most of Their Limit Right Now
+// ────────────────────────────────────────────────────────────────────────
+//
+// SCAN walks every rate:* key (each master of a cluster), so the summary
+// needs no bookkeeping on the request path. SCAN is incremental and never
+// blocks Redis, but it does visit every key: the walk stops after
+// maxStatsKeys and the result is marked truncated.
+//
+// Counts mean what they mean in the admin inspect API: requests in the
+// window, breaches, or — for the buckets — tokens left / queue level.
+// Token buckets are ranked by fewest tokens left, everything else by
+// highest count. Bans are not ranked.
+// ────────────────────────────────────────────────────────────────────────
+
+// maxStatsKeys bounds how many keys one Stats call reads.
+const maxStatsKeys = 10000
+
+// IdentifierCount is one identifier's count in one mode.
+type IdentifierCount struct {
+	Identifier string
+	Count      int64
+}
+
+// Stats summarises the state the store holds.
+type Stats struct {
+	Scanned   int                          // keys read
+	Truncated bool                         // the scan stopped at maxStatsKeys
+	Top       map[string][]IdentifierCount // mode → heaviest identifiers first
+}
+
+// StatsStore is implemented by stores that can rank identifiers by usage.
+// RedisStore and MemoryStore both implement it.
+type StatsStore interface {
+	// Stats returns up to n identifiers per mode, heaviest first.
+	Stats(ctx context.Context, n int) (*Stats, error)
+}
+
+// parseStateKey splits a state key into its mode and identifier.
+func parseStateKey(key string) (mode, identifier string, ok bool) {
+	for _, k := range stateKeys {
+		if rest, found := strings.CutPrefix(key, k.prefix+"{"); found && strings.HasSuffix(rest, "}") {
+			return k.mode, rest[:len(rest)-1], true
+		}
+	}
+	return "", "", false
+}
+
+// rankStats sorts each mode's identifiers heaviest first and keeps n.
+func rankStats(stats *Stats, n int) *Stats {
+	for mode, ids := range stats.Top {
+		sort.Slice(ids, func(i, j int) bool {
+			if mode == "token_bucket" {
+				return ids[i].Count < ids[j].Count // fewest tokens left
+			}
+			return ids[i].Count > ids[j].Count
+		})
+		stats.Top[mode] = ids[:min(n, len(ids))]
+	}
+	return stats
+}
+
+// Stats implements StatsStore with SCAN and one pipelined read per key.
+func (s *RedisStore) Stats(ctx context.Context, n int) (*Stats, error) {
+	client, ok := s.rdb.(redis.UniversalClient)
+	if !ok {
+		return nil, errors.New("stats need a Redis client that supports SCAN")
+	}
+
+	keys, truncated, err := scanStateKeys(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("stats scan error: %w", err)
+	}
+
+	type read struct {
+		mode, id string
+		cmd      redis.Cmder
+	}
+	reads := make([]read, 0, len(keys))
+	pipe := client.Pipeline()
+	for _, key := range keys {
+		mode, id, ok := parseStateKey(key)
+		if !ok || mode == "ban" {
+			continue
+		}
+		var cmd redis.Cmder
+		switch mode {
+		case "sliding":
+			cmd = pipe.ZCard(ctx, key)
+		case "sliding_counter":
+			cmd = pipe.HGet(ctx, key, "cur")
+		case "token_bucket":
+			cmd = pipe.HGet(ctx, key, "tokens")
+		case "leaky_bucket":
+			cmd = pipe.HGet(ctx, key, "level")
+		default: // fixed, breaches
+			cmd = pipe.Get(ctx, key)
+		}
+		reads = append(reads, read{mode, id, cmd})
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("stats read error: %w", err)
+	}
+
+	stats := &Stats{Scanned: len(keys), Truncated: truncated, Top: map[string][]IdentifierCount{}}
+	for _, r := range reads {
+		var count float64
+		switch cmd := r.cmd.(type) {
+		case *redis.IntCmd:
+			count = float64(cmd.Val())
+		case *redis.StringCmd:
+			v, err := cmd.Float64()
+			if err != nil {
+				continue // expired between SCAN and the read
+			}
+			count = v
+		}
+		stats.Top[r.mode] = append(stats.Top[r.mode], IdentifierCount{r.id, int64(math.Floor(count))})
+	}
+	return rankStats(stats, n), nil
+}
+
+// scanStateKeys returns the rate:* keys in client, from every master of a
+// cluster, stopping after maxStatsKeys.
+func scanStateKeys(ctx context.Context, client redis.UniversalClient) ([]string, bool, error) {
+	var (
+		mu        sync.Mutex
+		keys      []string
+		truncated bool
+	)
+	scan := func(ctx context.Context, node redis.Cmdable) error {
+		var cursor uint64
+		for {
+			batch, next, err := node.Scan(ctx, cursor, "rate:*", 1000).Result()
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			keys = append(keys, batch...)
+			full := len(keys) >= maxStatsKeys
+			if full {
+				keys, truncated = keys[:maxStatsKeys], true
+			}
+			mu.Unlock()
+
+			if full || next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	}
+
+	var err error
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node)
+		})
+	} else {
+		err = scan(ctx, client)
+	}
+	return keys, truncated, err
+}
+
+// Stats implements StatsStore over the in-memory entries.
+func (m *MemoryStore) Stats(_ context.Context, n int) (*Stats, error) {
+	now := time.Now()
+	stats := &Stats{Top: map[string][]IdentifierCount{}}
+
+	for i := range m.shards {
+		sh := &m.shards[i]
+		sh.mu.Lock()
+		for key, e := range sh.entries {
+			mode, id, ok := parseStateKey(key)
+			if !ok || mode == "ban" || !e.evictAt.After(now) {
+				continue
+			}
+			if stats.Scanned == maxStatsKeys {
+				stats.Truncated = true
+				break
+			}
+			stats.Scanned++
+			stats.Top[mode] = append(stats.Top[mode], IdentifierCount{id, e.storedCount(mode)})
+		}
+		sh.mu.Unlock()
+	}
+	return rankStats(stats, n), nil
+}
@@ -0,0 +1,77 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+)
+
+// Store is a rate-limit state backend. Each method performs one atomic
+// check-and-record for identifier, charging the request cost units, and
+// reports whether it is allowed, with the same semantics as the matching
+// Check* function.
+//
+// RedisStore is the production implementation shared by every GoShield
+// instance; MemoryStore keeps state in-process for local development and
+// tests that run without Redis.
+type Store interface {
+	FixedWindow(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*FixedWindowResult, error)
+	SlidingWindow(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*SlidingWindowResult, error)
+	SlidingCounter(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*SlidingCounterResult, error)
+	TokenBucket(ctx context.Context, identifier string, capacity int, refillPerSec float64, cost int) (*TokenBucketResult, error)
+	LeakyBucket(ctx context.Context, identifier string, capacity int, leakRatePerSec float64, cost int) (*LeakyBucketResult, error)
+	CalendarWindow(ctx context.Context, identifier string, limit int, period string, cost int) (*FixedWindowResult, error)
+}
+
+// RedisStore is a Store backed by the atomic Lua scripts in this package.
+type RedisStore struct {
+	rdb       RedisRunner
+	ttlJitter float64 // fixed window: ±fraction applied to each window's TTL
+}
+
+// NewRedisStore returns a Store that keeps all state in rdb.
+func NewRedisStore(rdb RedisRunner) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+// WithTTLJitter spreads each new fixed window's TTL by ±jitter (e.g. 0.1
+// for ±10%) so keys created together do not all expire at once. It
+// returns s for chaining.
+func (s *RedisStore) WithTTLJitter(jitter float64) *RedisStore {
+	s.ttlJitter = jitter
+	return s
+}
+
+// FixedWindow runs CheckFixedWindow against the store's Redis, applying
+// the store's TTL jitter.
+func (s *RedisStore) FixedWindow(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*FixedWindowResult, error) {
+	return checkFixedWindow(ctx, s.rdb, identifier, limit, windowSeconds, cost, s.ttlJitter)
+}
+
+// SlidingWindow runs CheckSlidingWindow against the store's Redis.
+func (s *RedisStore) SlidingWindow(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*SlidingWindowResult, error) {
+	return CheckSlidingWindow(ctx, s.rdb, identifier, limit, windowSeconds, cost)
+}
+
+// SlidingCounter runs CheckSlidingCounter against the store's Redis.
+func (s *RedisStore) SlidingCounter(ctx context.Context, identifier string, limit int, windowSeconds int, cost int) (*SlidingCounterResult, error) {
+	return CheckSlidingCounter(ctx, s.rdb, identifier, limit, windowSeconds, cost)
+}
+
+// TokenBucket runs CheckTokenBucket against the store's Redis.
+func (s *RedisStore) TokenBucket(ctx context.Context, identifier string, capacity int, refillPerSec float64, cost int) (*TokenBucketResult, error) {
+	return CheckTokenBucket(ctx, s.rdb, identifier, capacity, refillPerSec, cost)
+}
+
+// LeakyBucket runs CheckLeakyBucket against the store's Redis.
+func (s *RedisStore) LeakyBucket(ctx context.Context, identifier string, capacity int, leakRatePerSec float64, cost int) (*LeakyBucketResult, error) {
+	return CheckLeakyBucket(ctx, s.rdb, identifier, capacity, leakRatePerSec, cost)
+}
+
+// checkCost rejects request costs below one unit, which would let a
+// request through without being counted.
+func checkCost(cost int) error {
+	if cost < 1 {
+		return fmt.Errorf("request cost must be at least 1, got %d", cost)
+	}
+	return nil
+}
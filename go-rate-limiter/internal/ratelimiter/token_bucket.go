@@ -0,0 +1,132 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Token-Bucket Rate Limiter — Burst-Friendly, Atomic Lua Script
+// ────────────────────────────────────────────────────────────────────────
+//
+// Algorithm (Redis Hash):
+//   1. HMGET   → read the stored token count and last-refill timestamp
+//   2. Refill  → add (elapsed × refill rate) tokens, capped at capacity
+//   3. Consume → take `cost` tokens if that many are available
+//   4. HSET    → persist the new token count and timestamp
+//   5. EXPIRE  → drop the key once the bucket would be full again
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ WHY O(1)?                                                         │
+// │                                                                    │
+// │  • The bucket is two fields in one hash — HMGET/HSET touch a      │
+// │    fixed number of fields regardless of traffic.                   │
+// │  • Refill is a single multiplication; no per-request history is   │
+// │    stored, unlike the sliding-window ZSET.                         │
+// └────────────────────────────────────────────────────────────────────┘
+//
+// ┌────────────────────────────────────────────────────────────────────┐
+// │ ZERO RACE CONDITIONS                                               │
+// │                                                                    │
+// │  • Read-refill-consume-write runs inside a single Lua script, so  │
+// │    two concurrent requests can never both spend the last token.   │
+// └────────────────────────────────────────────────────────────────────┘
+//
+// Trade-off vs Windows:
+//   • Allows short bursts up to `capacity` after a quiet period.
+//   • Long-run average is still bounded by the refill rate.
+//   • Constant memory per client (one small hash).
+// ────────────────────────────────────────────────────────────────────────
+
+// tokenBucketScript refills and consumes from a token bucket stored in a
+// Redis hash. Returns {allowed (0|1), whole tokens left}.
+//
+// Time complexity per call: O(1)
+// Race conditions:          None (atomic Lua script)
+var tokenBucketScript = redis.NewScript(`
+local key          = KEYS[1]
+local capacity     = tonumber(ARGV[1])
+local refill_rate  = tonumber(ARGV[2])   -- tokens per second
+local now          = tonumber(ARGV[3])   -- milliseconds
+local expire_sec   = tonumber(ARGV[4])
+local cost         = tonumber(ARGV[5])
+
+-- 1. Load the bucket; a missing key is a full bucket  — O(1)
+local bucket = redis.call("HMGET", key, "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts     = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+    tokens = capacity
+    ts     = now
+end
+
+-- 2. Refill for the time elapsed since the last call  — O(1)
+local elapsed = math.max(0, now - ts)
+tokens = math.min(capacity, tokens + (elapsed / 1000) * refill_rate)
+
+-- 3. Consume cost tokens if available                 — O(1)
+local allowed = 0
+if tokens >= cost then
+    tokens  = tokens - cost
+    allowed = 1
+end
+
+-- 4. Persist state and refresh TTL                    — O(1)
+redis.call("HSET", key, "tokens", tokens, "ts", now)
+redis.call("EXPIRE", key, expire_sec)
+
+return {allowed, math.floor(tokens)}
+`)
+
+// TokenBucketResult holds the outcome of a token-bucket rate-limit check.
+type TokenBucketResult struct {
+	Allowed      bool    // whether the request should be forwarded
+	Tokens       int64   // whole tokens left in the bucket after this request
+	Capacity     int     // maximum tokens the bucket can hold (burst size)
+	RefillPerSec float64 // tokens added back to the bucket per second
+}
+
+// CheckTokenBucket performs a token-bucket rate-limit check for the given
+// identifier. Each request consumes cost tokens (1 for a plain count);
+// tokens are refilled at refillPerSec up to capacity.
+//
+// Guarantees:
+//   - O(1) time complexity: one hash read and write per request.
+//   - Zero race conditions: all operations run in a single atomic Lua script.
+//   - Bursts of up to `capacity` requests are admitted after idle periods.
+func CheckTokenBucket(ctx context.Context, rdb RedisRunner, identifier string, capacity int, refillPerSec float64, cost int) (*TokenBucketResult, error) {
+	if err := checkCost(cost); err != nil {
+		return nil, err
+	}
+	if refillPerSec <= 0 {
+		return nil, fmt.Errorf("token bucket refill rate must be positive, got %v", refillPerSec)
+	}
+
+	now := time.Now().UnixMilli()                                     // millisecond precision
+	expireSec := int64(math.Ceil(float64(capacity)/refillPerSec)) + 1 // time to refill an empty bucket
+
+	key := redisKey("rate:bucket:", identifier)
+
+	res, err := tokenBucketScript.Run(ctx, rdb, []string{key},
+		capacity,     // ARGV[1]
+		refillPerSec, // ARGV[2]
+		now,          // ARGV[3]
+		expireSec,    // ARGV[4]
+		cost,         // ARGV[5]
+	).Int64Slice()
+
+	if err != nil {
+		return nil, fmt.Errorf("token bucket script error: %w", err)
+	}
+
+	return &TokenBucketResult{
+		Allowed:      res[0] == 1,
+		Tokens:       res[1],
+		Capacity:     capacity,
+		RefillPerSec: refillPerSec,
+	}, nil
+}
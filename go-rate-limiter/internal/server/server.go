@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// Graceful Shutdown — Drain In-Flight Requests on SIGTERM / SIGINT
+// ────────────────────────────────────────────────────────────────────────
+//
+//   SIGTERM ──► stop accepting connections
+//           ──► wait for active requests (incl. proxied ones) to finish,
+//               up to the shutdown timeout
+//           ──► return, so main can close Redis and flush traces
+//
+// Kubernetes sends SIGTERM on every rolling deploy; without draining,
+// requests still being proxied upstream are cut off and clients see 502s.
+// ────────────────────────────────────────────────────────────────────────
+
+// Run serves handler on addr until the process receives SIGTERM or SIGINT,
+// then shuts the server down gracefully, giving in-flight requests up to
+// shutdownTimeout to complete. It returns nil after a clean shutdown.
+func Run(addr string, handler http.Handler, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err // failed to start (e.g. port in use)
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process immediately
+
+	slog.Info("shutting down, draining in-flight requests", "timeout", shutdownTimeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("server stopped")
+	return nil
+}
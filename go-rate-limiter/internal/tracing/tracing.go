@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/ThishaniDissanayake/GoShield/go-rate-limiter/internal/logging"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ────────────────────────────────────────────────────────────────────────
+// OpenTelemetry Tracing — Opt-In, Zero-Cost When Off
+// ────────────────────────────────────────────────────────────────────────
+//
+// With OTEL_ENABLED=true GoShield exports spans over OTLP/HTTP (endpoint
+// and headers come from the standard OTEL_EXPORTER_OTLP_* env vars):
+//
+//   <incoming W3C traceparent>
+//     └─ GET /route              (server span, Middleware)
+//          ├─ ratelimit.<mode>   (StartCheck / EndCheck)
+//          └─ proxy.forward      (StartProxy / EndProxy, context
+//                                 injected into the upstream request)
+//
+// When tracing is off every helper returns immediately without touching
+// the OpenTelemetry API, so the hot path pays only a bool check.
+// ────────────────────────────────────────────────────────────────────────
+
+const instrumentationName = "github.com/ThishaniDissanayake/GoShield/go-rate-limiter"
+
+var (
+	enabled bool
+	tracer  trace.Tracer
+)
+
+// Setup installs the OTLP/HTTP tracer provider when OTEL_ENABLED is true
+// and returns a function that flushes and stops it. When tracing is off
+// the returned function is a no-op.
+func Setup() func(context.Context) error {
+	if v, err := strconv.ParseBool(os.Getenv("OTEL_ENABLED")); err != nil || !v {
+		return func(context.Context) error { return nil }
+	}
+
+	ctx := context.Background()
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		logging.Fatal("OTLP trace exporter setup failed", "err", err)
+	}
+
+	// Later options win, so OTEL_SERVICE_NAME overrides the default name.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "goshield")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		logging.Fatal("OpenTelemetry resource setup failed", "err", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	tracer = tp.Tracer(instrumentationName)
+	enabled = true
+	slog.Info("OpenTelemetry tracing enabled")
+
+	return tp.Shutdown
+}
+
+// Enabled reports whether Setup turned tracing on.
+func Enabled() bool {
+	return enabled
+}
+
+// Middleware returns a Gin middleware that continues the caller's trace
+// (from traceparent / baggage headers) and wraps the request in a server
+// span. Register it before the rate limiter so limiter spans nest under it.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("url.path", c.Request.URL.Path),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+	}
+}
+
+// StartCheck starts the span around one rate-limit check, as a child of
+// the span in parent. The returned context is base carrying the new span,
+// so the store call keeps base's deadline and cancellation (not the
+// client's) while still being traced. Returns base and a nil span when
+// tracing is off.
+func StartCheck(parent, base context.Context, mode, identifier string) (context.Context, trace.Span) {
+	if !enabled {
+		return base, nil
+	}
+
+	_, span := tracer.Start(parent, "ratelimit."+mode,
+		trace.WithAttributes(
+			attribute.String("goshield.mode", mode),
+			attribute.String("goshield.identifier_hash", hashIdentifier(identifier)),
+		),
+	)
+	return trace.ContextWithSpan(base, span), span
+}
+
+// EndCheck records the decision (or error) on a span from StartCheck and
+// ends it. A nil span is ignored.
+func EndCheck(span trace.Span, allowed bool, err error) {
+	if span == nil {
+		return
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		decision := "allow"
+		if !allowed {
+			decision = "block"
+		}
+		span.SetAttributes(attribute.String("goshield.decision", decision))
+	}
+	span.End()
+}
+
+// StartProxy starts a client span for forwarding c's request upstream and
+// injects the trace context into the outgoing request headers so the
+// upstream can continue the trace. Returns nil when tracing is off.
+func StartProxy(c *gin.Context) trace.Span {
+	if !enabled {
+		return nil
+	}
+
+	ctx, span := tracer.Start(c.Request.Context(), "proxy.forward",
+		trace.WithSpanKind(trace.SpanKindClient),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(c.Request.Header))
+	c.Request = c.Request.WithContext(ctx)
+
+	return span
+}
+
+// EndProxy records the upstream status on a span from StartProxy and ends
+// it. A nil span is ignored.
+func EndProxy(span trace.Span, status int) {
+	if span == nil {
+		return
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= 500 {
+		span.SetStatus(codes.Error, "")
+	}
+	span.End()
+}
+
+// hashIdentifier returns a short SHA-256 digest of identifier so spans
+// can correlate requests from one client without exporting raw IPs or
+// API keys.
+func hashIdentifier(identifier string) string {
+	sum := sha256.Sum256([]byte(identifier))
+	return hex.EncodeToString(sum[:8])
+}